@@ -3,28 +3,171 @@
 // @description API для управления онлайн-подписками пользователей
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT в формате "Bearer <token>"
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description API-ключ сервиса в формате "ApiKey <key>"
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/IlyaStarshinov/onlineSubscriptions/docs"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/health"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/logging"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/metrics"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/ratelimit"
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/repository"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/seed"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/server"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/tracing"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/webhook"
 
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/handler"
 )
 
 func main() {
-	db, err := repository.InitRepository()
+	cfg, err := config.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("config load error", err)
+	}
+	logger := logging.New(cfg.Log, os.Stdout)
+	slog.SetDefault(logger)
+	slog.Info("effective configuration", "config", cfg)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("failed to initialize tracing", err)
+	}
+
+	db, err := repository.InitRepository(cfg, logger)
 	if err != nil {
-		log.Fatalf("failed to initialize database, got error %v", err)
+		fatal("failed to initialize database", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		fatal("failed to initialize database tracing", err)
+	}
+	if cfg.Seed.Users > 0 {
+		n, err := seed.Populate(context.Background(), db, seed.Options{
+			Users: cfg.Seed.Users,
+			OrgID: model.DefaultOrgID,
+			Seed:  uint64(cfg.Seed.Random),
+		})
+		if err != nil {
+			fatal("failed to generate demo data", err)
+		}
+		slog.Info("demo data generated", "users", cfg.Seed.Users, "subscriptions", n)
+	}
+
+	var m *metrics.Metrics
+	if cfg.Features.Metrics {
+		m, err = metrics.New(db, cfg.DB.Name)
+		if err != nil {
+			fatal("failed to initialize metrics", err)
+		}
+	}
+
+	apiKeys := auth.NewAPIKeys(db)
+	var authenticator *auth.Authenticator
+	var keys *auth.KeySet
+	if cfg.Auth.Enabled {
+		if cfg.Auth.JWKSFile != "" || cfg.Auth.JWKSURL != "" {
+			keys, err = auth.NewKeySet(context.Background(), cfg.Auth.JWKSFile, cfg.Auth.JWKSURL)
+			if err != nil {
+				fatal("failed to load jwks", err)
+			}
+		}
+		authenticator = auth.NewAuthenticator(cfg.Auth, keys, apiKeys)
+	} else {
+		slog.Warn("authentication is disabled by auth-enabled=false, all requests have admin access")
 	}
 
-	router := handler.SetupRouter(db)
+	policy := auth.DefaultPolicy()
+	if cfg.Auth.PolicyFile != "" {
+		policy, err = auth.LoadPolicy(cfg.Auth.PolicyFile)
+		if err != nil {
+			fatal("failed to load access policy", err)
+		}
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.New(cfg.RateLimit)
+	}
 
-	log.Println("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		log.Fatalf("failed to start server, got error %v", err)
+	var webhooks *webhook.Webhooks
+	if cfg.Webhooks.Enabled {
+		webhooks = webhook.New(db, cfg.Webhooks)
+	}
+
+	readiness := health.NewRegistry()
+	router := handler.SetupRouter(handler.Deps{
+		DB:        db,
+		Config:    cfg,
+		Readiness: readiness,
+		Metrics:   m,
+		Auth:      authenticator,
+		APIKeys:   apiKeys,
+		Policy:    policy,
+		RateLimit: limiter,
+		Webhooks:  webhooks,
+	})
+
+	srv := server.New(cfg.HTTP, router)
+	if m != nil {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", m.Handler())
+		srv.AddInternal("metrics", cfg.Features.MetricsAddr, metricsMux)
+	}
+	if keys != nil {
+		srv.AddWorker("jwks-refresh", keys.RefreshLoop(cfg.Auth.JWKSRefresh))
 	}
+	if limiter != nil {
+		srv.AddWorker("rate-limit-sweep", limiter.SweepLoop(time.Minute))
+	}
+	if webhooks != nil {
+		srv.AddWorker("webhook-delivery", webhooks.DeliveryLoop())
+		srv.AddWorker("webhook-schedule", webhooks.ScheduleLoop())
+	}
+	readiness.Add("database", repository.Ping(db))
+	readiness.Add("migrations", repository.CheckMigrations(db))
+	readiness.Add("workers", srv.CheckWorkers)
+	srv.OnDrain(readiness.SetDraining)
+	srv.OnShutdown("database", func(context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+	srv.OnShutdown("tracing", shutdownTracing)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := srv.Run(ctx); err != nil {
+		fatal("server error", err)
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,310 @@
+// Команда subadmin обслуживает базу данных сервиса напрямую, без HTTP API:
+// миграции, демонстрационные данные, нормализация, очистка и проверка целостности.
+//
+//	subadmin verify -o json
+//	subadmin normalize-names -dry-run
+//
+// Подключение к базе настраивается так же, как у сервера: файлом -config (CONFIG_FILE),
+// переменными DB_* и .env.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/logging"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/maintenance"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/repository"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/seed"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Коды завершения; exitIssues — verify нашёл нарушения
+const (
+	exitOK     = 0
+	exitError  = 1
+	exitUsage  = 2
+	exitIssues = 3
+)
+
+// env окружение команды: база, журнал и формат вывода
+type env struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	out    io.Writer
+	json   bool
+}
+
+// command подкоманда subadmin; setup регистрирует флаги и возвращает функцию выполнения
+type command struct {
+	name    string
+	summary string
+	setup   func(fs *flag.FlagSet) func(ctx context.Context, e *env) (int, error)
+}
+
+var commands = []command{
+	{"migrate", "Create or update tables and the default organization", migrateCmd},
+	{"seed", "Insert demo subscriptions or generate them for N users", seedCmd},
+	{"normalize-names", "Merge spellings of the same service name within an organization", normalizeNamesCmd},
+	{"normalize-dates", "Move subscription, pause and price change dates to the first day of the month", normalizeDatesCmd},
+	{"purge", "Delete revoked and expired API keys", purgeCmd},
+	{"verify", "Check data integrity and print a report", verifyCmd},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "subadmin: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("subadmin "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "", "path to YAML or TOML config file (env CONFIG_FILE)")
+	output := fs.String("o", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 10*time.Minute, "command timeout")
+	exec := cmd.setup(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 || (*output != "table" && *output != "json") {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var configArgs []string
+	if *configPath != "" {
+		configArgs = []string{"-config", *configPath}
+	}
+	// subadmin не обслуживает запросы API, настройки аутентификации ему не нужны
+	configArgs = append(configArgs, "-auth-enabled=false")
+	cfg, err := config.LoadConfig(configArgs)
+	if err != nil {
+		fmt.Fprintf(stderr, "subadmin: config load error: %v\n", err)
+		return exitError
+	}
+	logger := logging.New(cfg.Log, stderr)
+	slog.SetDefault(logger)
+	db, err := repository.Open(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(stderr, "subadmin: %v\n", err)
+		return exitError
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	code, err := exec(ctx, &env{db: db, logger: logger, out: stdout, json: *output == "json"})
+	if err != nil {
+		fmt.Fprintf(stderr, "subadmin %s: %v\n", cmd.name, err)
+		if code == exitOK {
+			code = exitError
+		}
+	}
+	return code
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "subadmin — database maintenance for the Online Subscriptions service")
+	fmt.Fprintln(w, "\nUsage: subadmin <command> [flags]\n\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w, "\nThere is no recompute command: the database stores no derived data. Summaries, forecasts,")
+	fmt.Fprintln(w, "renewals and metrics are calculated on request; dates written around the API are fixed by normalize-dates.")
+	fmt.Fprintln(w, "\nRun 'subadmin <command> -h' for command flags.")
+}
+
+func migrateCmd(_ *flag.FlagSet) func(context.Context, *env) (int, error) {
+	return func(ctx context.Context, e *env) (int, error) {
+		if err := repository.Migrate(e.db.WithContext(ctx), e.logger); err != nil {
+			return exitError, err
+		}
+		fmt.Fprintln(e.out, "migrations applied")
+		return exitOK, nil
+	}
+}
+
+// seedCmd без -users создаёт фиксированный демонстрационный набор, с -users — сгенерированные данные
+func seedCmd(fs *flag.FlagSet) func(context.Context, *env) (int, error) {
+	org := fs.String("org", model.DefaultOrgID.String(), "organization UUID for the demo data")
+	users := fs.Int("users", 0, "generate subscriptions for this many users instead of the fixed demo set")
+	random := fs.Uint64("random", 1, "random seed for -users; the same seed generates the same data")
+	return func(ctx context.Context, e *env) (int, error) {
+		orgID, err := uuid.Parse(*org)
+		if err != nil {
+			return exitUsage, fmt.Errorf("invalid -org: %w", err)
+		}
+		if *users < 0 {
+			return exitUsage, errors.New("-users must not be negative")
+		}
+		var n int
+		if *users > 0 {
+			n, err = seed.Populate(ctx, e.db, seed.Options{Users: *users, OrgID: orgID, Seed: *random})
+		} else {
+			n, err = seed.Demo(ctx, e.db, orgID)
+		}
+		if err != nil {
+			return exitError, err
+		}
+		if e.json {
+			return exitOK, printJSON(e.out, map[string]any{"subscriptions": n})
+		}
+		if n == 0 {
+			fmt.Fprintln(e.out, "demo data already present")
+			return exitOK, nil
+		}
+		fmt.Fprintf(e.out, "created %d demo subscriptions\n", n)
+		return exitOK, nil
+	}
+}
+
+func normalizeNamesCmd(fs *flag.FlagSet) func(context.Context, *env) (int, error) {
+	dryRun := fs.Bool("dry-run", false, "only print planned renames")
+	return func(ctx context.Context, e *env) (int, error) {
+		renames, err := maintenance.NormalizeServiceNames(ctx, e.db, *dryRun)
+		if err != nil {
+			return exitError, err
+		}
+		if e.json {
+			return exitOK, printJSON(e.out, renames)
+		}
+		tw := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ORG\tFROM\tTO\tROWS")
+		for _, r := range renames {
+			fmt.Fprintf(tw, "%s\t%q\t%q\t%d\n", r.OrgID, r.From, r.To, r.Rows)
+		}
+		tw.Flush()
+		fmt.Fprintf(e.out, "%d renames%s\n", len(renames), dryRunNote(*dryRun))
+		return exitOK, nil
+	}
+}
+
+func normalizeDatesCmd(fs *flag.FlagSet) func(context.Context, *env) (int, error) {
+	dryRun := fs.Bool("dry-run", false, "only count dates that would change")
+	return func(ctx context.Context, e *env) (int, error) {
+		fixes, err := maintenance.NormalizeDates(ctx, e.db, *dryRun)
+		if err != nil {
+			return exitError, err
+		}
+		if e.json {
+			return exitOK, printJSON(e.out, fixes)
+		}
+		tw := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TABLE\tCOLUMN\tROWS")
+		for _, f := range fixes {
+			fmt.Fprintf(tw, "%s\t%s\t%d\n", f.Table, f.Column, f.Rows)
+		}
+		tw.Flush()
+		if *dryRun {
+			fmt.Fprintln(e.out, "dry run, nothing changed")
+		}
+		return exitOK, nil
+	}
+}
+
+func purgeCmd(fs *flag.FlagSet) func(context.Context, *env) (int, error) {
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "keep keys revoked or expired more recently than this")
+	dryRun := fs.Bool("dry-run", false, "only count keys that would be deleted")
+	return func(ctx context.Context, e *env) (int, error) {
+		n, err := maintenance.PurgeAPIKeys(ctx, e.db, time.Now().Add(-*olderThan), *dryRun)
+		if err != nil {
+			return exitError, err
+		}
+		if e.json {
+			return exitOK, printJSON(e.out, map[string]any{"api_keys": n, "dry_run": *dryRun})
+		}
+		fmt.Fprintf(e.out, "%d API keys purged%s\n", n, dryRunNote(*dryRun))
+		return exitOK, nil
+	}
+}
+
+// verifyCmd печатает отчёт о целостности и завершается с кодом 3, если нашлись нарушения
+func verifyCmd(fs *flag.FlagSet) func(context.Context, *env) (int, error) {
+	samples := fs.Int("samples", 5, "IDs of offending rows to list per check")
+	return func(ctx context.Context, e *env) (int, error) {
+		report, err := maintenance.Verify(ctx, e.db, *samples)
+		if err != nil {
+			return exitError, err
+		}
+		code := exitOK
+		if !report.OK() {
+			code = exitIssues
+		}
+		if e.json {
+			return code, printJSON(e.out, report)
+		}
+		tw := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CHECK\tTABLE\tCOUNT\tSAMPLES")
+		for _, c := range report.Checks {
+			status := "ok"
+			if c.Count > 0 {
+				status = fmt.Sprint(c.Count)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, c.Table, status, strings.Join(c.Samples, ","))
+		}
+		tw.Flush()
+		if report.OK() {
+			fmt.Fprintln(e.out, "\nno integrity issues found")
+			return code, nil
+		}
+		fmt.Fprintf(e.out, "\n%d rows violate integrity checks:\n", report.Issues)
+		for _, c := range report.Checks {
+			if c.Count > 0 {
+				fmt.Fprintf(e.out, "  %s: %s\n", c.Name, c.Description)
+			}
+		}
+		return code, nil
+	}
+}
+
+func dryRunNote(dryRun bool) string {
+	if dryRun {
+		return " (dry run, nothing changed)"
+	}
+	return ""
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
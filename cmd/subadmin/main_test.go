@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestRunUsage проверяет разбор команд и флагов; до подключения к базе эти случаи не доходят
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{"no command", nil, exitUsage, "Commands:"},
+		{"help", []string{"help"}, exitOK, "no recompute command"},
+		{"unknown command", []string{"recompute"}, exitUsage, `unknown command "recompute"`},
+		{"command help", []string{"purge", "-h"}, exitOK, "-older-than"},
+		{"unknown flag", []string{"verify", "-force"}, exitUsage, "flag provided but not defined"},
+		{"invalid output", []string{"verify", "-o", "yaml"}, exitUsage, "-samples"},
+		{"extra argument", []string{"migrate", "now"}, exitUsage, "-timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.code {
+				t.Errorf("run(%q) = %d, want %d; stderr:\n%s", tt.args, code, tt.code, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr does not contain %q:\n%s", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
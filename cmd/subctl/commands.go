@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/pkg/client"
+	"github.com/google/uuid"
+)
+
+// exportFormats форматы export по имени флага -format
+var exportFormats = map[string]string{
+	"csv":    client.FormatCSV,
+	"xlsx":   client.FormatXLSX,
+	"ndjson": client.FormatNDJSON,
+}
+
+func listCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	user := fs.String("user", "", "only subscriptions of this user UUID")
+	pageSize := fs.Int("page-size", client.DefaultPageSize, "subscriptions per API request (1-1000)")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) > 0 {
+			return usagef("unexpected arguments %v", args)
+		}
+		userID, err := optionalUUID("user", *user)
+		if err != nil {
+			return err
+		}
+		p := a.subscriptionPrinter()
+		for sub, err := range a.client.Subscriptions(ctx, client.ListOptions{UserID: userID, Limit: *pageSize}) {
+			if err != nil {
+				return err
+			}
+			if err := p.print(sub); err != nil {
+				return err
+			}
+		}
+		return p.flush()
+	}
+}
+
+// getCmd показывает подписку по id. У API нет запроса подписки по id,
+// поэтому она ищется в списке, доступном вызывающей стороне.
+func getCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	user := fs.String("user", "", "owner UUID, narrows the search to the user's subscriptions")
+	return func(ctx context.Context, a *app, args []string) error {
+		id, err := singleID(args)
+		if err != nil {
+			return err
+		}
+		userID, err := optionalUUID("user", *user)
+		if err != nil {
+			return err
+		}
+		for sub, err := range a.client.Subscriptions(ctx, client.ListOptions{UserID: userID, Limit: 1000}) {
+			if err != nil {
+				return err
+			}
+			if sub.ID == id {
+				return a.printOne(&sub)
+			}
+		}
+		return fmt.Errorf("subscription %s not found", id)
+	}
+}
+
+func createCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	var in client.CreateSubscriptionRequest
+	var user, end, trial string
+	fs.StringVar(&in.ServiceName, "service", "", "service name (required)")
+	fs.IntVar(&in.Price, "price", 0, "price per billing period")
+	fs.StringVar(&user, "user", "", "user UUID (required)")
+	fs.StringVar(&in.StartDate, "start", "", "start month MM-YYYY (required)")
+	fs.StringVar(&end, "end", "", "end month MM-YYYY")
+	fs.StringVar(&in.BillingPeriod, "period", "", "billing period: monthly, quarterly, semiannual or yearly")
+	fs.StringVar(&trial, "trial-end", "", "first paid month MM-YYYY")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) > 0 {
+			return usagef("unexpected arguments %v", args)
+		}
+		var err error
+		if in.UserID, err = uuid.Parse(user); err != nil {
+			return usagef("-user must be a UUID")
+		}
+		in.EndDate = optionalString(end)
+		in.TrialEndDate = optionalString(trial)
+		sub, err := a.client.CreateSubscription(ctx, in)
+		if err != nil {
+			return err
+		}
+		return a.printOne(sub)
+	}
+}
+
+// updateCmd меняет только поля, флаги которых указаны явно
+func updateCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	service := fs.String("service", "", "service name")
+	price := fs.Int("price", 0, "price per billing period")
+	start := fs.String("start", "", "start month MM-YYYY")
+	end := fs.String("end", "", "end month MM-YYYY")
+	period := fs.String("period", "", "billing period: monthly, quarterly, semiannual or yearly")
+	trial := fs.String("trial-end", "", "first paid month MM-YYYY")
+	return func(ctx context.Context, a *app, args []string) error {
+		id, err := singleID(args)
+		if err != nil {
+			return err
+		}
+		var in client.UpdateSubscriptionRequest
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "service":
+				in.ServiceName = service
+			case "price":
+				in.Price = price
+			case "start":
+				in.StartDate = start
+			case "end":
+				in.EndDate = end
+			case "period":
+				in.BillingPeriod = period
+			case "trial-end":
+				in.TrialEndDate = trial
+			}
+		})
+		if in == (client.UpdateSubscriptionRequest{}) {
+			return usagef("nothing to update: set at least one field flag")
+		}
+		sub, err := a.client.UpdateSubscription(ctx, id, in)
+		if err != nil {
+			return err
+		}
+		return a.printOne(sub)
+	}
+}
+
+func deleteCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) == 0 {
+			return usagef("subscription ID is required")
+		}
+		ids := make([]uuid.UUID, len(args))
+		for i, arg := range args {
+			id, err := uuid.Parse(arg)
+			if err != nil {
+				return usagef("invalid subscription ID %q", arg)
+			}
+			ids[i] = id
+		}
+		for _, id := range ids {
+			if err := a.client.DeleteSubscription(ctx, id); err != nil {
+				return fmt.Errorf("delete %s: %w", id, err)
+			}
+			fmt.Fprintf(a.stderr, "deleted %s\n", id)
+		}
+		return nil
+	}
+}
+
+func summaryCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	var p client.SummaryParams
+	var user string
+	fs.StringVar(&user, "user", "", "user UUID")
+	fs.StringVar(&p.ServiceName, "service", "", "service name")
+	fs.StringVar(&p.StartDate, "start", "", "first month MM-YYYY")
+	fs.StringVar(&p.EndDate, "end", "", "last month MM-YYYY")
+	fs.IntVar(&p.FiscalYear, "fiscal-year", 0, "fiscal year of the organization instead of -start and -end")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) > 0 {
+			return usagef("unexpected arguments %v", args)
+		}
+		var err error
+		if p.UserID, err = optionalUUID("user", user); err != nil {
+			return err
+		}
+		s, err := a.client.Summary(ctx, p)
+		if err != nil {
+			return err
+		}
+		return a.printRecord(s, []string{"total_price", "currency"}, []string{strconv.Itoa(s.TotalPrice), s.Currency})
+	}
+}
+
+func exportCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	user := fs.String("user", "", "only subscriptions of this user UUID")
+	format := fs.String("format", "csv", "export format: csv, xlsx or ndjson")
+	out := fs.String("out", "", "output file, default stdout")
+	return func(ctx context.Context, a *app, args []string) (err error) {
+		if len(args) > 0 {
+			return usagef("unexpected arguments %v", args)
+		}
+		mediaType, ok := exportFormats[*format]
+		if !ok {
+			return usagef("unknown export format %q", *format)
+		}
+		userID, err := optionalUUID("user", *user)
+		if err != nil {
+			return err
+		}
+		var w io.Writer = a.stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err = errors.Join(err, f.Close())
+			}()
+			w = f
+		}
+		return a.client.ExportSubscriptions(ctx, userID, mediaType, w)
+	}
+}
+
+func singleID(args []string) (uuid.UUID, error) {
+	if len(args) != 1 {
+		return uuid.Nil, usagef("exactly one subscription ID is required")
+	}
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return uuid.Nil, usagef("invalid subscription ID %q", args[0])
+	}
+	return id, nil
+}
+
+func optionalUUID(name, s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, usagef("-%s must be a UUID", name)
+	}
+	return id, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// completionArgs значения позиционных аргументов и флагов, которые дополняются явно
+var completionArgs = map[string]string{
+	"profile":    "list show use set delete",
+	"completion": "bash zsh fish",
+	"-o":         "table json csv",
+	"-format":    "csv json ndjson xlsx",
+}
+
+// completionCmd печатает скрипт автодополнения. Команды и флаги берутся из самого subctl,
+// поэтому скрипт не нужно править при их изменении — достаточно сгенерировать заново.
+func completionCmd(_ *flag.FlagSet) func(context.Context, *app, []string) error {
+	return func(_ context.Context, a *app, args []string) error {
+		if len(args) != 1 {
+			return usagef("shell is required: bash, zsh or fish")
+		}
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(a.stdout)
+		case "zsh":
+			fmt.Fprintln(a.stdout, "#compdef subctl\nautoload -U +X bashcompinit && bashcompinit")
+			writeBashCompletion(a.stdout)
+		case "fish":
+			writeFishCompletion(a.stdout)
+		default:
+			return usagef("unsupported shell %q", args[0])
+		}
+		return nil
+	}
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, `_subctl() {
+    local cur prev cmd
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    if [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=($(compgen -W %q -- "$cur"))
+        return
+    fi
+    cmd="${COMP_WORDS[1]}"
+    case "$prev" in
+        -o) COMPREPLY=($(compgen -W %q -- "$cur")); return ;;
+        -format) COMPREPLY=($(compgen -W %q -- "$cur")); return ;;
+        -out|-config) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+    esac
+    case "$cmd" in
+`, commandNames(), completionArgs["-o"], completionArgs["-format"])
+	for _, c := range commands {
+		words := "-" + strings.Join(commandFlags(c), " -")
+		if extra, ok := completionArgs[c.name]; ok && !strings.HasPrefix(extra, "-") {
+			words = extra + " " + words
+		}
+		fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.name, words)
+	}
+	fmt.Fprint(w, `    esac
+    if [[ "$cmd" == "import" && "$cur" != -* ]]; then
+        COMPREPLY+=($(compgen -f -- "$cur"))
+    fi
+}
+complete -F _subctl subctl
+`)
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintf(w, "complete -c subctl -f\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c subctl -n __fish_use_subcommand -a %s -d %q\n", c.name, c.summary)
+		for _, name := range commandFlags(c) {
+			fmt.Fprintf(w, "complete -c subctl -n '__fish_seen_subcommand_from %s' -o %s", c.name, name)
+			if values, ok := completionArgs["-"+name]; ok {
+				fmt.Fprintf(w, " -x -a %q", values)
+			}
+			fmt.Fprintln(w)
+		}
+		if values, ok := completionArgs[c.name]; ok {
+			fmt.Fprintf(w, "complete -c subctl -n '__fish_seen_subcommand_from %s' -a %q\n", c.name, values)
+		}
+	}
+	fmt.Fprintln(w, "complete -c subctl -n '__fish_seen_subcommand_from import' -F")
+}
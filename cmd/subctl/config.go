@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/pkg/client"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultBaseURL адрес сервиса, если он не задан ни флагом, ни окружением, ни профилем
+const defaultBaseURL = "http://localhost:8080"
+
+// defaultProfile имя профиля, если профиль не выбран
+const defaultProfile = "default"
+
+// profile параметры подключения к одному экземпляру сервиса
+type profile struct {
+	BaseURL  string `yaml:"base_url,omitempty" json:"base_url,omitempty"`
+	Token    string `yaml:"token,omitempty" json:"token,omitempty"`
+	APIKey   string `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+	OrgID    string `yaml:"org_id,omitempty" json:"org_id,omitempty"`
+	Language string `yaml:"language,omitempty" json:"language,omitempty"`
+}
+
+// fileConfig файл конфигурации subctl
+type fileConfig struct {
+	CurrentProfile string             `yaml:"current_profile,omitempty"`
+	Profiles       map[string]profile `yaml:"profiles,omitempty"`
+}
+
+// globalOptions общие флаги всех команд. Пустые значения берутся из переменных SUBCTL_*,
+// затем из профиля.
+type globalOptions struct {
+	configPath string
+	profile    string
+	conn       profile
+	output     string
+	timeout    time.Duration
+}
+
+// registerGlobalFlags регистрирует общие флаги в fs
+func registerGlobalFlags(fs *flag.FlagSet) *globalOptions {
+	o := &globalOptions{}
+	fs.StringVar(&o.configPath, "config", "", "config file (env SUBCTL_CONFIG, default "+displayConfigPath()+")")
+	fs.StringVar(&o.profile, "profile", "", "config profile (env SUBCTL_PROFILE, default current_profile from the config file)")
+	fs.StringVar(&o.conn.BaseURL, "base-url", "", "API base URL (env SUBCTL_BASE_URL, default "+defaultBaseURL+")")
+	fs.StringVar(&o.conn.Token, "token", "", "JWT bearer token (env SUBCTL_TOKEN)")
+	fs.StringVar(&o.conn.APIKey, "api-key", "", "API key (env SUBCTL_API_KEY)")
+	fs.StringVar(&o.conn.OrgID, "org", "", "organization UUID sent as X-Org-ID (env SUBCTL_ORG)")
+	fs.StringVar(&o.conn.Language, "lang", "", "language of error messages: en or ru (env SUBCTL_LANG)")
+	fs.StringVar(&o.output, "o", outputTable, "output format: table, json or csv")
+	fs.DurationVar(&o.timeout, "timeout", defaultTimeout, "command timeout, 0 disables it")
+	return o
+}
+
+// resolve возвращает параметры подключения: флаги, затем окружение, затем профиль
+func (o *globalOptions) resolve() (profile, error) {
+	cfg, err := loadFileConfig(o.path())
+	if err != nil {
+		return profile{}, err
+	}
+	name := firstNonEmpty(o.profile, os.Getenv("SUBCTL_PROFILE"), cfg.CurrentProfile, defaultProfile)
+	p, ok := cfg.Profiles[name]
+	if !ok && (o.profile != "" || os.Getenv("SUBCTL_PROFILE") != "") {
+		return profile{}, fmt.Errorf("profile %q not found in %s", name, o.path())
+	}
+	resolved := profile{
+		BaseURL:  firstNonEmpty(o.conn.BaseURL, os.Getenv("SUBCTL_BASE_URL"), p.BaseURL, defaultBaseURL),
+		OrgID:    firstNonEmpty(o.conn.OrgID, os.Getenv("SUBCTL_ORG"), p.OrgID),
+		Language: firstNonEmpty(o.conn.Language, os.Getenv("SUBCTL_LANG"), p.Language),
+	}
+	// Токен и ключ берутся парой с одного уровня: токен из флага заменяет и ключ из профиля
+	for _, creds := range [][2]string{
+		{o.conn.Token, o.conn.APIKey},
+		{os.Getenv("SUBCTL_TOKEN"), os.Getenv("SUBCTL_API_KEY")},
+		{p.Token, p.APIKey},
+	} {
+		if creds[0] != "" || creds[1] != "" {
+			resolved.Token, resolved.APIKey = creds[0], creds[1]
+			break
+		}
+	}
+	return resolved, nil
+}
+
+// newClient создаёт клиент API по итоговым параметрам подключения
+func (o *globalOptions) newClient() (*client.Client, error) {
+	switch o.output {
+	case outputTable, outputJSON, outputCSV:
+	default:
+		return nil, fmt.Errorf("unknown output format %q", o.output)
+	}
+	p, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+	cfg := client.Config{BaseURL: p.BaseURL, Token: p.Token, APIKey: p.APIKey, Language: p.Language, UserAgent: "subctl"}
+	if p.OrgID != "" {
+		if cfg.OrgID, err = uuid.Parse(p.OrgID); err != nil {
+			return nil, fmt.Errorf("invalid organization ID %q", p.OrgID)
+		}
+	}
+	return client.New(cfg)
+}
+
+// path возвращает путь к файлу конфигурации
+func (o *globalOptions) path() string {
+	if o.configPath != "" {
+		return o.configPath
+	}
+	if p := os.Getenv("SUBCTL_CONFIG"); p != "" {
+		return p
+	}
+	return defaultConfigPath()
+}
+
+// defaultConfigPath возвращает $XDG_CONFIG_HOME/subctl/config.yaml или его аналог для ОС
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "subctl.yaml"
+	}
+	return filepath.Join(dir, "subctl", "config.yaml")
+}
+
+// displayConfigPath путь к файлу конфигурации для справки, с ~ вместо домашнего каталога
+func displayConfigPath() string {
+	p := defaultConfigPath()
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, p); err == nil && filepath.IsLocal(rel) {
+			return filepath.Join("~", rel)
+		}
+	}
+	return p
+}
+
+// loadFileConfig читает файл конфигурации; отсутствующий файл равен пустой конфигурации
+func loadFileConfig(path string) (*fileConfig, error) {
+	cfg := &fileConfig{Profiles: map[string]profile{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]profile{}
+	}
+	return cfg, nil
+}
+
+// saveFileConfig записывает файл конфигурации; в нём хранятся секреты, поэтому права 0600
+func saveFileConfig(path string, cfg *fileConfig) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
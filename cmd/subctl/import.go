@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/pkg/client"
+	"github.com/google/uuid"
+)
+
+// importRecord подписка во входном файле. Формат совпадает с выгрузкой сервера:
+// id, org_id и прочие поля игнорируются, даты принимаются как MM-YYYY или RFC 3339.
+type importRecord struct {
+	ServiceName   string  `json:"service_name"`
+	Price         int     `json:"price"`
+	UserID        string  `json:"user_id"`
+	StartDate     string  `json:"start_date"`
+	EndDate       *string `json:"end_date"`
+	BillingPeriod string  `json:"billing_period"`
+	TrialEndDate  *string `json:"trial_end_date"`
+
+	// pos место записи во входных данных для сообщений об ошибках
+	pos string
+}
+
+// importCmd создаёт подписки из файла. Каждая запись создаётся отдельным запросом;
+// по умолчанию импорт останавливается на первой ошибке.
+func importCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	format := fs.String("format", "", "input format: csv, json or ndjson (default: by file extension)")
+	dryRun := fs.Bool("dry-run", false, "parse and check the input without creating subscriptions")
+	keepGoing := fs.Bool("continue", false, "continue after failed records")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 1 {
+			return usagef("exactly one input file is required, - for stdin")
+		}
+		name := args[0]
+		if *format == "" {
+			*format = strings.TrimPrefix(filepath.Ext(name), ".")
+		}
+		var r io.Reader = os.Stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		records, err := readRecords(r, *format)
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			for _, rec := range records {
+				if _, err := rec.request(); err != nil {
+					return fmt.Errorf("%s: %w", rec.pos, err)
+				}
+			}
+			fmt.Fprintf(a.stderr, "%d subscriptions ready to import\n", len(records))
+			return nil
+		}
+
+		p := a.subscriptionPrinter()
+		var failed int
+		for _, rec := range records {
+			sub, err := rec.create(ctx, a.client)
+			if err != nil {
+				if !*keepGoing || ctx.Err() != nil {
+					p.flush()
+					return fmt.Errorf("%s: %w", rec.pos, err)
+				}
+				failed++
+				fmt.Fprintf(a.stderr, "%s: ", rec.pos)
+				printError(a.stderr, err)
+				continue
+			}
+			if err := p.print(*sub); err != nil {
+				return err
+			}
+		}
+		if err := p.flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "imported %d of %d subscriptions\n", len(records)-failed, len(records))
+		if failed > 0 {
+			return fmt.Errorf("%d subscriptions failed to import", failed)
+		}
+		return nil
+	}
+}
+
+// readRecords читает все записи входных данных в формате format
+func readRecords(r io.Reader, format string) ([]importRecord, error) {
+	switch format {
+	case "csv":
+		return readCSV(r)
+	case "json":
+		var records []importRecord
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("parse JSON: %w", err)
+		}
+		for i := range records {
+			records[i].pos = "record " + strconv.Itoa(i+1)
+		}
+		return records, nil
+	case "ndjson":
+		return readNDJSON(r)
+	case "":
+		return nil, usagef("cannot detect input format, use -format")
+	}
+	return nil, usagef("unknown input format %q", format)
+}
+
+// readCSV читает CSV с заголовком; колонки ищутся по имени, как в выгрузке сервера
+func readCSV(r io.Reader) ([]importRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		// Excel сохраняет CSV в UTF-8 с BOM перед первым заголовком
+		col[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, required := range []string{"service_name", "price", "user_id", "start_date"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("CSV column %q is missing", required)
+		}
+	}
+	var records []importRecord
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := col[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		rec := importRecord{
+			ServiceName:   field("service_name"),
+			UserID:        field("user_id"),
+			StartDate:     field("start_date"),
+			EndDate:       optionalString(field("end_date")),
+			BillingPeriod: field("billing_period"),
+			TrialEndDate:  optionalString(field("trial_end_date")),
+			pos:           "line " + strconv.Itoa(line),
+		}
+		if rec.Price, err = strconv.Atoi(field("price")); err != nil {
+			return nil, fmt.Errorf("%s: invalid price %q", rec.pos, field("price"))
+		}
+		records = append(records, rec)
+	}
+}
+
+// readNDJSON читает по одной подписке в строке, пустые строки пропускаются
+func readNDJSON(r io.Reader) ([]importRecord, error) {
+	var records []importRecord
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		rec := importRecord{pos: "line " + strconv.Itoa(line)}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s: %w", rec.pos, err)
+		}
+		records = append(records, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read NDJSON: %w", err)
+	}
+	return records, nil
+}
+
+// request переводит запись в запрос создания подписки. Остальные поля проверит сервер.
+func (rec importRecord) request() (client.CreateSubscriptionRequest, error) {
+	userID, err := uuid.Parse(rec.UserID)
+	if err != nil {
+		return client.CreateSubscriptionRequest{}, fmt.Errorf("invalid user_id %q", rec.UserID)
+	}
+	return client.CreateSubscriptionRequest{
+		ServiceName:   rec.ServiceName,
+		Price:         rec.Price,
+		UserID:        userID,
+		StartDate:     normalizeMonth(rec.StartDate),
+		EndDate:       normalizeOptionalMonth(rec.EndDate),
+		BillingPeriod: rec.BillingPeriod,
+		TrialEndDate:  normalizeOptionalMonth(rec.TrialEndDate),
+	}, nil
+}
+
+func (rec importRecord) create(ctx context.Context, c *client.Client) (*client.Subscription, error) {
+	in, err := rec.request()
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateSubscription(ctx, in)
+}
+
+// normalizeMonth переводит дату RFC 3339 из JSON-выгрузки в MM-YYYY; прочие значения не меняет
+func normalizeMonth(s string) string {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return client.Month(t)
+	}
+	return s
+}
+
+func normalizeOptionalMonth(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	m := normalizeMonth(*s)
+	return &m
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadRecords(t *testing.T) {
+	const user = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "csv export with BOM and extra columns",
+			format: "csv",
+			input: "\ufeffid,service_name,price,user_id,start_date,end_date,billing_period\n" +
+				"x,Netflix,400," + user + ",07-2025,,monthly\n" +
+				"y, Yandex Plus ,299," + user + ",08-2025,12-2025,yearly\n",
+			want: []string{
+				"line 2: Netflix 400 07-2025 <nil> monthly",
+				"line 3: Yandex Plus 299 08-2025 12-2025 yearly",
+			},
+		},
+		{
+			name:   "csv columns in any order",
+			format: "csv",
+			input:  "start_date,user_id,price,service_name\n07-2025," + user + ",1,Kion\n",
+			want:   []string{"line 2: Kion 1 07-2025 <nil> "},
+		},
+		{
+			name:   "csv line numbers account for quoted newlines",
+			format: "csv",
+			input:  "service_name,price,user_id,start_date\n\"Two\nlines\",1," + user + ",07-2025\nKion,2," + user + ",07-2025\n",
+			want:   []string{"line 2: Two\nlines 1 07-2025 <nil> ", "line 4: Kion 2 07-2025 <nil> "},
+		},
+		{name: "csv missing column", format: "csv", input: "service_name,price,start_date\n", wantErr: `CSV column "user_id" is missing`},
+		{name: "csv invalid price", format: "csv", input: "service_name,price,user_id,start_date\nKion,free," + user + ",07-2025\n",
+			wantErr: `line 2: invalid price "free"`},
+		{name: "csv empty", format: "csv", input: "", wantErr: "read CSV header"},
+		{
+			name:   "json export",
+			format: "json",
+			input: `[{"id":"x","service_name":"Netflix","price":400,"user_id":"` + user + `","start_date":"2025-07-01T00:00:00Z"},
+				{"service_name":"Kion","price":1,"user_id":"` + user + `","start_date":"07-2025","end_date":"08-2025"}]`,
+			want: []string{
+				"record 1: Netflix 400 2025-07-01T00:00:00Z <nil> ",
+				"record 2: Kion 1 07-2025 08-2025 ",
+			},
+		},
+		{name: "json not an array", format: "json", input: `{"service_name":"Kion"}`, wantErr: "parse JSON"},
+		{
+			name:   "ndjson skips blank lines",
+			format: "ndjson",
+			input:  `{"service_name":"Netflix","price":400,"user_id":"` + user + `","start_date":"07-2025"}` + "\n\n  \n" + `{"service_name":"Kion","price":1}` + "\n",
+			want: []string{
+				"line 1: Netflix 400 07-2025 <nil> ",
+				"line 4: Kion 1  <nil> ",
+			},
+		},
+		{name: "ndjson reports the broken line", format: "ndjson", input: "{\"price\":1}\n{\"price\":\"one\"}\n", wantErr: "line 2:"},
+		{name: "no format", format: "", input: "", wantErr: "cannot detect input format"},
+		{name: "unknown format", format: "xml", input: "", wantErr: `unknown input format "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := readRecords(strings.NewReader(tt.input), tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readRecords() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, rec := range records {
+				end := "<nil>"
+				if rec.EndDate != nil {
+					end = *rec.EndDate
+				}
+				got = append(got, fmt.Sprintf("%s: %s %d %s %s %s", rec.pos, rec.ServiceName, rec.Price, rec.StartDate, end, rec.BillingPeriod))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("records:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestReadRecordsUsageErrors(t *testing.T) {
+	for _, format := range []string{"", "xml"} {
+		_, err := readRecords(strings.NewReader(""), format)
+		var usage usageError
+		if !errors.As(err, &usage) {
+			t.Errorf("readRecords(format %q) error = %v, want a usage error", format, err)
+		}
+	}
+}
+
+func TestImportRecordRequest(t *testing.T) {
+	const user = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	strPtr := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		rec     importRecord
+		want    string
+		wantErr string
+	}{
+		{
+			name: "months are kept",
+			rec:  importRecord{ServiceName: "Netflix", Price: 400, UserID: user, StartDate: "07-2025", EndDate: strPtr("12-2025"), BillingPeriod: "yearly"},
+			want: "Netflix 400 07-2025 12-2025 <nil> yearly",
+		},
+		{
+			name: "RFC 3339 dates from JSON export",
+			rec:  importRecord{ServiceName: "Kion", UserID: user, StartDate: "2025-07-01T00:00:00Z", TrialEndDate: strPtr("2025-08-01T00:00:00Z")},
+			want: "Kion 0 07-2025 <nil> 08-2025 ",
+		},
+		{
+			name: "empty optional dates are omitted",
+			rec:  importRecord{ServiceName: "Kion", UserID: user, StartDate: "07-2025", EndDate: strPtr(""), TrialEndDate: strPtr("")},
+			want: "Kion 0 07-2025 <nil> <nil> ",
+		},
+		{
+			name: "other values are left to the server",
+			rec:  importRecord{ServiceName: "Kion", UserID: user, StartDate: "July"},
+			want: "Kion 0 July <nil> <nil> ",
+		},
+		{name: "invalid user", rec: importRecord{UserID: "me"}, wantErr: `invalid user_id "me"`},
+	}
+	opt := func(s *string) string {
+		if s == nil {
+			return "<nil>"
+		}
+		return *s
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := tt.rec.request()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("request() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if in.UserID.String() != user {
+				t.Errorf("user_id = %s, want %s", in.UserID, user)
+			}
+			got := fmt.Sprintf("%s %d %s %s %s %s", in.ServiceName, in.Price, in.StartDate, opt(in.EndDate), opt(in.TrialEndDate), in.BillingPeriod)
+			if got != tt.want {
+				t.Errorf("request() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
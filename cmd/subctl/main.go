@@ -0,0 +1,219 @@
+// Команда subctl — клиент командной строки для API сервиса онлайн-подписок.
+//
+//	subctl list -o table
+//	subctl create -service "Yandex Plus" -price 400 -user <uuid> -start 07-2025
+//	subctl export -format csv -out subs.csv
+//
+// Адрес сервиса и учётные данные берутся из флагов, переменных SUBCTL_* или профиля
+// в файле конфигурации (subctl profile set).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/pkg/client"
+)
+
+// Коды завершения
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command подкоманда subctl. setup регистрирует флаги команды и возвращает функцию,
+// которая выполняет её с разобранными флагами и позиционными аргументами.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// local команда не обращается к API и не требует клиента
+	local bool
+	setup func(fs *flag.FlagSet) func(ctx context.Context, a *app, args []string) error
+}
+
+// commands все подкоманды в порядке вывода в справке.
+// Заполняется в init: completion сам обходит этот список.
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "list", summary: "List subscriptions", setup: listCmd},
+		{name: "get", args: "<id>", summary: "Show a subscription", setup: getCmd},
+		{name: "create", summary: "Create a subscription", setup: createCmd},
+		{name: "update", args: "<id>", summary: "Update subscription fields given as flags", setup: updateCmd},
+		{name: "delete", args: "<id>...", summary: "Delete subscriptions", setup: deleteCmd},
+		{name: "summary", summary: "Total price of subscriptions for a period", setup: summaryCmd},
+		{name: "import", args: "<file|->", summary: "Create subscriptions from CSV, JSON or NDJSON", setup: importCmd},
+		{name: "export", summary: "Export subscriptions as CSV, XLSX or NDJSON", setup: exportCmd},
+		{name: "profile", args: "list|show|use|set|delete [name]", summary: "Manage config profiles", local: true, setup: profileCmd},
+		{name: "completion", args: "bash|zsh|fish", summary: "Print a shell completion script", local: true, setup: completionCmd},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run выполняет subctl с аргументами args и возвращает код завершения
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "subctl: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("subctl "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts := registerGlobalFlags(fs)
+	exec := cmd.setup(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: subctl %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	a := &app{opts: opts, stdout: stdout, stderr: stderr}
+	if !cmd.local {
+		if a.client, err = opts.newClient(); err != nil {
+			fmt.Fprintf(stderr, "subctl: %v\n", err)
+			return exitUsage
+		}
+		if opts.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+			defer cancel()
+		}
+	}
+
+	err = exec(ctx, a, positional)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "subctl %s: %v\n", cmd.name, err)
+		fs.Usage()
+		return exitUsage
+	default:
+		printError(stderr, err)
+		return exitError
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "subctl — command-line client for the Online Subscriptions API")
+	fmt.Fprintln(w, "\nUsage: subctl <command> [flags] [args]\n\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w, "\nRun 'subctl <command> -h' for command flags.")
+}
+
+// usageError ошибка в аргументах команды: выводится вместе со справкой по флагам
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+// parseInterspersed разбирает флаги, стоящие как до, так и после позиционных аргументов:
+// subctl get <id> -o json. Всё после "--" считается позиционными аргументами.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// printError выводит ошибку; у ошибок API — код, идентификатор запроса и нарушения по полям
+func printError(w io.Writer, err error) {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "error: %s", apiErr.Message)
+	if apiErr.Code != "" {
+		fmt.Fprintf(w, " (%s", apiErr.Code)
+		if apiErr.RequestID != "" {
+			fmt.Fprintf(w, ", request %s", apiErr.RequestID)
+		}
+		fmt.Fprint(w, ")")
+	}
+	fmt.Fprintln(w)
+	for _, v := range apiErr.Violations {
+		fmt.Fprintf(w, "  %s: %s\n", v.Pointer, v.Detail)
+	}
+}
+
+// commandFlags возвращает имена флагов команды, включая общие, для автодополнения
+func commandFlags(c command) []string {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	registerGlobalFlags(fs)
+	c.setup(fs)
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	sort.Strings(names)
+	return names
+}
+
+// commandNames возвращает имена всех подкоманд через пробел
+func commandNames() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return strings.Join(names, " ")
+}
+
+// defaultTimeout таймаут одной команды, обращающейся к API
+const defaultTimeout = 60 * time.Second
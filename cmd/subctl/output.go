@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/pkg/client"
+)
+
+// Форматы вывода
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+// app окружение выполнения команды
+type app struct {
+	opts   *globalOptions
+	client *client.Client
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// subscriptionColumns колонки таблицы и CSV, совпадают с выгрузкой сервера,
+// поэтому вывод list -o csv можно загрузить обратно через import
+var subscriptionColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_period", "trial_end_date"}
+
+// subscriptionPrinter построчно выводит подписки в формате -o. JSON собирается в массив
+// и выводится в flush, таблица и CSV пишутся сразу.
+type subscriptionPrinter struct {
+	format string
+	w      io.Writer
+	table  *tabwriter.Writer
+	csv    *csv.Writer
+	items  []client.Subscription
+}
+
+func (a *app) subscriptionPrinter() *subscriptionPrinter {
+	p := &subscriptionPrinter{format: a.opts.output, w: a.stdout, items: []client.Subscription{}}
+	switch p.format {
+	case outputTable:
+		p.table = tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(p.table, "ID\tSERVICE\tPRICE\tUSER\tSTART\tEND\tPERIOD\tTRIAL END")
+	case outputCSV:
+		p.csv = csv.NewWriter(a.stdout)
+		p.csv.Write(subscriptionColumns)
+	}
+	return p
+}
+
+func (p *subscriptionPrinter) print(sub client.Subscription) error {
+	row := subscriptionRow(sub)
+	switch p.format {
+	case outputTable:
+		_, err := fmt.Fprintf(p.table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			row[0], row[1], row[2], row[3], row[4], dash(row[5]), row[6], dash(row[7]))
+		return err
+	case outputCSV:
+		return p.csv.Write(row)
+	default:
+		p.items = append(p.items, sub)
+		return nil
+	}
+}
+
+func (p *subscriptionPrinter) flush() error {
+	switch p.format {
+	case outputTable:
+		return p.table.Flush()
+	case outputCSV:
+		p.csv.Flush()
+		return p.csv.Error()
+	default:
+		return printJSON(p.w, p.items)
+	}
+}
+
+// printOne выводит одну подписку: в JSON — объектом, а не массивом
+func (a *app) printOne(sub *client.Subscription) error {
+	if a.opts.output == outputJSON {
+		return printJSON(a.stdout, sub)
+	}
+	p := a.subscriptionPrinter()
+	if err := p.print(*sub); err != nil {
+		return err
+	}
+	return p.flush()
+}
+
+// printRecord выводит значение v в JSON, а в таблицу и CSV — строку values с заголовками header
+func (a *app) printRecord(v any, header, values []string) error {
+	switch a.opts.output {
+	case outputJSON:
+		return printJSON(a.stdout, v)
+	case outputCSV:
+		w := csv.NewWriter(a.stdout)
+		w.Write(header)
+		w.Write(values)
+		w.Flush()
+		return w.Error()
+	default:
+		tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		for i := range header {
+			fmt.Fprintf(tw, "%s:\t%s\n", header[i], values[i])
+		}
+		return tw.Flush()
+	}
+}
+
+func subscriptionRow(sub client.Subscription) []string {
+	return []string{
+		sub.ID.String(),
+		sub.ServiceName,
+		strconv.Itoa(sub.Price),
+		sub.UserID.String(),
+		sub.StartDate.Format(client.MonthLayout),
+		formatMonth(sub.EndDate),
+		sub.BillingPeriod,
+		formatMonth(sub.TrialEndDate),
+	}
+}
+
+func formatMonth(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(client.MonthLayout)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
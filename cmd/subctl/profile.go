@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+)
+
+// profileCmd управляет профилями файла конфигурации. Значения профиля в set задаются
+// общими флагами подключения: subctl profile set prod -base-url https://... -token ...
+func profileCmd(fs *flag.FlagSet) func(context.Context, *app, []string) error {
+	return func(_ context.Context, a *app, args []string) error {
+		if len(args) == 0 {
+			return usagef("profile action is required: list, show, use, set or delete")
+		}
+		path := a.opts.path()
+		cfg, err := loadFileConfig(path)
+		if err != nil {
+			return err
+		}
+		action, args := args[0], args[1:]
+		name := firstNonEmpty(a.opts.profile, cfg.CurrentProfile, defaultProfile)
+		if len(args) > 1 {
+			return usagef("too many arguments")
+		}
+		if len(args) == 1 {
+			name = args[0]
+		}
+
+		saved := "saved to"
+		switch action {
+		case "list":
+			names := make([]string, 0, len(cfg.Profiles))
+			for n := range cfg.Profiles {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+			for _, n := range names {
+				mark := " "
+				if n == firstNonEmpty(cfg.CurrentProfile, defaultProfile) {
+					mark = "*"
+				}
+				fmt.Fprintf(tw, "%s %s\t%s\n", mark, n, cfg.Profiles[n].BaseURL)
+			}
+			return tw.Flush()
+		case "show":
+			p, ok := cfg.Profiles[name]
+			if !ok {
+				return fmt.Errorf("profile %q not found in %s", name, path)
+			}
+			return a.printRecord(maskProfile(p), []string{"profile", "base_url", "token", "api_key", "org_id", "language"},
+				[]string{name, p.BaseURL, mask(p.Token), mask(p.APIKey), p.OrgID, p.Language})
+		case "use":
+			if _, ok := cfg.Profiles[name]; !ok {
+				return fmt.Errorf("profile %q not found in %s", name, path)
+			}
+			cfg.CurrentProfile = name
+		case "set":
+			p := cfg.Profiles[name]
+			fs.Visit(func(f *flag.Flag) {
+				switch f.Name {
+				case "base-url":
+					p.BaseURL = a.opts.conn.BaseURL
+				case "token":
+					p.Token, p.APIKey = a.opts.conn.Token, ""
+				case "api-key":
+					p.APIKey, p.Token = a.opts.conn.APIKey, ""
+				case "org":
+					p.OrgID = a.opts.conn.OrgID
+				case "lang":
+					p.Language = a.opts.conn.Language
+				}
+			})
+			if p.Token != "" && p.APIKey != "" {
+				return usagef("-token and -api-key are mutually exclusive")
+			}
+			if p.OrgID != "" {
+				if _, err := uuid.Parse(p.OrgID); err != nil {
+					return usagef("-org must be a UUID")
+				}
+			}
+			cfg.Profiles[name] = p
+			if len(cfg.Profiles) == 1 && cfg.CurrentProfile == "" {
+				cfg.CurrentProfile = name
+			}
+		case "delete":
+			if _, ok := cfg.Profiles[name]; !ok {
+				return fmt.Errorf("profile %q not found in %s", name, path)
+			}
+			delete(cfg.Profiles, name)
+			saved = "deleted from"
+			if cfg.CurrentProfile == name {
+				cfg.CurrentProfile = ""
+			}
+		default:
+			return usagef("unknown profile action %q", action)
+		}
+		if err := saveFileConfig(path, cfg); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "profile %q %s %s\n", name, saved, path)
+		return nil
+	}
+}
+
+// maskProfile возвращает профиль со скрытыми секретами для вывода
+func maskProfile(p profile) profile {
+	p.Token, p.APIKey = mask(p.Token), mask(p.APIKey)
+	return p
+}
+
+// mask оставляет от секрета первые символы, чтобы профили можно было различить
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return secret[:4] + strings.Repeat("*", 4)
+}
@@ -0,0 +1,89 @@
+// Package apperr описывает доменные ошибки сервиса. Вид ошибки (Kind) определяет ответ API
+// независимо от того, где она возникла: в обработчике, в проверке данных или в хранилище.
+package apperr
+
+import (
+	"errors"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+)
+
+// Kind вид доменной ошибки
+type Kind uint8
+
+const (
+	// KindInternal непредвиденная ошибка; подробности только в журнале
+	KindInternal Kind = iota
+	// KindNotFound запрошенной записи нет
+	KindNotFound
+	// KindConflict операция противоречит существующим данным: дубликат или связанные записи
+	KindConflict
+	// KindValidation данные запроса некорректны, повторять его без изменений бессмысленно
+	KindValidation
+	// KindUnavailable хранилище временно недоступно, запрос можно повторить
+	KindUnavailable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindUnavailable:
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
+
+// Error доменная ошибка. Code — машиночитаемый код и ключ сообщения в каталоге i18n,
+// Args — аргументы сообщения, Err — исходная ошибка для журнала.
+type Error struct {
+	Kind Kind
+	Code string
+	Args []any
+	Err  error
+}
+
+// New создаёт ошибку вида kind с кодом code
+func New(kind Kind, code string, args ...any) *Error {
+	return &Error{Kind: kind, Code: code, Args: args}
+}
+
+// Wrap создаёт ошибку вида kind с кодом code поверх исходной ошибки err
+func Wrap(kind Kind, code string, err error) *Error {
+	return &Error{Kind: kind, Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return e.Code + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf возвращает вид ошибки: у validation.Errors это KindValidation,
+// у ошибок, не переведённых в доменные, — KindInternal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		return KindValidation
+	}
+	return KindInternal
+}
+
+// IsKind сообщает, что err — доменная ошибка вида kind
+func IsKind(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
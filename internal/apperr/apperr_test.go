@@ -0,0 +1,84 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+)
+
+func TestKindOf(t *testing.T) {
+	var v validation.Validator
+	v.Required("/service_name", "")
+	notFound := New(KindNotFound, "subscription_not_found")
+
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"domain error", notFound, KindNotFound},
+		{"wrapped domain error", fmt.Errorf("get: %w", notFound), KindNotFound},
+		{"domain error over a cause", Wrap(KindUnavailable, "service_unavailable", errors.New("dial tcp")), KindUnavailable},
+		{"validation errors", v.Err(), KindValidation},
+		{"wrapped validation errors", fmt.Errorf("create: %w", v.Err()), KindValidation},
+		{"plain error", errors.New("boom"), KindInternal},
+		{"nil", nil, KindInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsKind(t *testing.T) {
+	if IsKind(nil, KindInternal) {
+		t.Error("IsKind(nil, KindInternal) = true, want false")
+	}
+	if !IsKind(New(KindConflict, "already_exists"), KindConflict) {
+		t.Error("IsKind(conflict, KindConflict) = false")
+	}
+	if IsKind(New(KindConflict, "already_exists"), KindNotFound) {
+		t.Error("IsKind(conflict, KindNotFound) = true")
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("duplicate key")
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{"code only", New(KindNotFound, "pause_not_found"), "pause_not_found"},
+		{"code and cause", Wrap(KindConflict, "already_exists", cause), "already_exists: duplicate key"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("%s: Error() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if !errors.Is(Wrap(KindConflict, "already_exists", cause), cause) {
+		t.Error("Wrap() does not unwrap to the cause")
+	}
+}
+
+func TestKindString(t *testing.T) {
+	kinds := map[Kind]string{
+		KindInternal:    "internal",
+		KindNotFound:    "not_found",
+		KindConflict:    "conflict",
+		KindValidation:  "validation",
+		KindUnavailable: "unavailable",
+		Kind(200):       "internal",
+	}
+	for k, want := range kinds {
+		if got := k.String(); got != want {
+			t.Errorf("Kind(%d).String() = %q, want %q", k, got, want)
+		}
+	}
+}
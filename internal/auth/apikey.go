@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/apperr"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefix метка, с которой начинаются все ключи: упрощает поиск утёкших ключей сканерами секретов
+const apiKeyPrefix = "osk_"
+
+// apiKeyIDLength длина случайной открытой части ключа в байтах
+const apiKeyIDLength = 6
+
+// lastUsedResolution как часто обновляется last_used_at, чтобы не писать в базу на каждый запрос
+const lastUsedResolution = time.Minute
+
+// ErrAPIKeyNotFound ключ не существует или уже отозван
+var ErrAPIKeyNotFound = apperr.New(apperr.KindNotFound, "api_key_not_found")
+
+// APIKeys выпуск, ротация, отзыв и проверка API-ключей
+type APIKeys struct {
+	db *gorm.DB
+}
+
+// NewAPIKeys создаёт хранилище API-ключей поверх базы данных
+func NewAPIKeys(db *gorm.DB) *APIKeys {
+	return &APIKeys{db: db}
+}
+
+// Issue выпускает ключ организации orgID и возвращает его запись вместе с открытым значением,
+// которое больше нигде не хранится
+func (k *APIKeys) Issue(ctx context.Context, orgID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	prefix, raw, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &model.APIKey{
+		OrgID:     orgID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(raw),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		ExpiresAt: expiresAt,
+	}
+	if err := k.db.WithContext(ctx).Create(key).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to store api key: %w", err)
+	}
+	return key, raw, nil
+}
+
+// List возвращает все ключи организации, включая отозванные и истёкшие
+func (k *APIKeys) List(ctx context.Context, orgID uuid.UUID) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := k.db.WithContext(ctx).Where("org_id = ?", orgID).Order("created_at").Find(&keys).Error
+	return keys, err
+}
+
+// Rotate заменяет секрет действующего ключа организации, сохраняя его идентификатор, области и срок действия.
+// Старое значение перестаёт работать сразу.
+func (k *APIKeys) Rotate(ctx context.Context, orgID, id uuid.UUID) (*model.APIKey, string, error) {
+	prefix, raw, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	res := k.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND org_id = ? AND revoked_at IS NULL", id, orgID).
+		Updates(map[string]any{"prefix": prefix, "hash": hashAPIKey(raw), "rotated_at": now})
+	if res.Error != nil {
+		return nil, "", fmt.Errorf("failed to rotate api key: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, "", ErrAPIKeyNotFound
+	}
+	var key model.APIKey
+	if err := k.db.WithContext(ctx).First(&key, "id = ?", id).Error; err != nil {
+		return nil, "", err
+	}
+	return &key, raw, nil
+}
+
+// Revoke отзывает ключ организации; запись остаётся для аудита
+func (k *APIKeys) Revoke(ctx context.Context, orgID, id uuid.UUID) error {
+	res := k.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND org_id = ? AND revoked_at IS NULL", id, orgID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return fmt.Errorf("failed to revoke api key: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate проверяет ключ и возвращает вызывающую сторону с его областями действия
+func (k *APIKeys) Authenticate(ctx context.Context, raw string) (*Principal, error) {
+	prefix, ok := parseAPIKey(raw)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed api key", ErrUnauthorized)
+	}
+	var key model.APIKey
+	err := k.db.WithContext(ctx).Where("prefix = ? AND revoked_at IS NULL", prefix).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: unknown or revoked api key", ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(raw))) != 1 {
+		return nil, fmt.Errorf("%w: api key secret mismatch", ErrUnauthorized)
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, fmt.Errorf("%w: api key expired", ErrUnauthorized)
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		// Отметка использования вспомогательная, её сбой не должен отклонять запрос
+		err := k.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", key.ID).UpdateColumn("last_used_at", now).Error
+		if err != nil {
+			slog.WarnContext(ctx, "failed to update api key last use", "key_id", key.ID, "error", err)
+		}
+	}
+	return &Principal{Subject: "apikey:" + key.ID.String(), OrgID: key.OrgID, KeyID: key.ID, Scopes: key.Scopes}, nil
+}
+
+// generateAPIKey возвращает ключ вида osk_<12 hex>_<43 base64url> и его открытый префикс
+func generateAPIKey() (prefix, raw string, err error) {
+	id := make([]byte, apiKeyIDLength)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	prefix = apiKeyPrefix + hex.EncodeToString(id)
+	return prefix, prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// parseAPIKey выделяет открытый префикс из ключа
+func parseAPIKey(raw string) (prefix string, ok bool) {
+	rest, ok := strings.CutPrefix(raw, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(id) != apiKeyIDLength*2 || secret == "" {
+		return "", false
+	}
+	return apiKeyPrefix + id, true
+}
+
+// hashAPIKey у ключа 256 бит случайности, поэтому медленный KDF не нужен
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
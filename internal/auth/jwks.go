@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwksFetchTimeout ограничивает загрузку набора ключей по URL
+const jwksFetchTimeout = 10 * time.Second
+
+// KeySet набор открытых RSA-ключей из JWKS-файла или URL
+type KeySet struct {
+	file string
+	url  string
+
+	mu   sync.RWMutex
+	keys map[string]*rsa.PublicKey
+}
+
+// NewKeySet создаёт набор ключей из локального файла или URL и загружает его
+func NewKeySet(ctx context.Context, file, url string) (*KeySet, error) {
+	ks := &KeySet{file: file, url: url}
+	if err := ks.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Key возвращает ключ по kid. Пустой kid допустим, если в наборе ровно один ключ.
+func (ks *KeySet) Key(kid string) (*rsa.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, nil
+		}
+	}
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return k, nil
+}
+
+// Refresh перечитывает набор ключей из источника
+func (ks *KeySet) Refresh(ctx context.Context) error {
+	var (
+		data []byte
+		err  error
+	)
+	if ks.file != "" {
+		data, err = os.ReadFile(ks.file)
+	} else {
+		data, err = fetch(ctx, ks.url)
+	}
+	if err != nil {
+		return fmt.Errorf("load jwks: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+// RefreshLoop периодически обновляет ключи до отмены ctx; подходит как фоновая задача сервера
+func (ks *KeySet) RefreshLoop(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				if err := ks.Refresh(ctx); err != nil {
+					// Оставляем прежние ключи: временная недоступность JWKS не должна ломать аутентификацию
+					slog.ErrorContext(ctx, "failed to refresh jwks", "error", err)
+				}
+			}
+		}
+	}
+}
+
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// parseJWKS извлекает RSA-ключи подписи; ключи других типов пропускаются
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid exponent: %w", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA signing keys found")
+	}
+	return keys, nil
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/apperr"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// clockSkew допустимое расхождение часов при проверке exp/nbf/iat
+const clockSkew = 30 * time.Second
+
+// ErrUnauthorized учётные данные отсутствуют или недействительны
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authenticator проверяет JWT bearer-токены (HS256 с общим секретом и RS256 с ключами JWKS)
+// и API-ключи сервисов
+type Authenticator struct {
+	cfg     config.AuthConfig
+	secret  []byte
+	keys    *KeySet
+	apiKeys *APIKeys
+	parser  *jwt.Parser
+}
+
+// NewAuthenticator создаёт проверку учётных данных; keys может быть nil, если RS256 не настроен,
+// apiKeys — nil, если API-ключи не принимаются
+func NewAuthenticator(cfg config.AuthConfig, keys *KeySet, apiKeys *APIKeys) *Authenticator {
+	methods := []string{}
+	if cfg.HS256Secret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if keys != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return &Authenticator{
+		cfg:     cfg,
+		secret:  []byte(cfg.HS256Secret),
+		keys:    keys,
+		apiKeys: apiKeys,
+		parser:  jwt.NewParser(opts...),
+	}
+}
+
+// Authenticate проверяет токен и возвращает вызывающую сторону.
+// Claim sub должен содержать UUID пользователя, роли, организация и язык берутся из настраиваемых claim
+// (по умолчанию roles, org_id и locale).
+func (a *Authenticator) Authenticate(raw string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(raw, claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrUnauthorized)
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sub claim is not a user UUID", ErrUnauthorized)
+	}
+	p := &Principal{Subject: sub, UserID: userID, Roles: stringList(claims[a.cfg.RolesClaim])}
+	if org, ok := claims[a.cfg.OrgClaim].(string); ok && org != "" {
+		if p.OrgID, err = uuid.Parse(org); err != nil {
+			return nil, fmt.Errorf("%w: %s claim is not an organization UUID", ErrUnauthorized, a.cfg.OrgClaim)
+		}
+	}
+	if locale, ok := claims[a.cfg.LocaleClaim].(string); ok {
+		p.Locale = locale
+	}
+	return p, nil
+}
+
+func (a *Authenticator) keyFunc(t *jwt.Token) (any, error) {
+	switch t.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+}
+
+// Middleware требует заголовок Authorization: Bearer <token> или Authorization: ApiKey <key>
+// и кладёт Principal в контекст запроса
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		credentials = strings.TrimSpace(credentials)
+		if credentials == "" {
+			unauthorized(w, r, "missing_credentials")
+			return
+		}
+		var (
+			p   *Principal
+			err error
+		)
+		switch {
+		case strings.EqualFold(scheme, "Bearer"):
+			p, err = a.Authenticate(credentials)
+		case strings.EqualFold(scheme, "ApiKey") && a.apiKeys != nil:
+			p, err = a.apiKeys.Authenticate(r.Context(), credentials)
+		default:
+			unauthorized(w, r, "unsupported_auth_scheme")
+			return
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			slog.InfoContext(r.Context(), "authentication failed", "scheme", scheme, "error", err)
+			unauthorized(w, r, "invalid_credentials")
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "authentication error", "scheme", scheme, "error", err)
+			if apperr.IsKind(repository.Translate(err), apperr.KindUnavailable) {
+				// Ключи хранятся в БД: при её недоступности клиент может повторить запрос
+				w.Header().Set("Retry-After", "5")
+				i18n.WriteError(w, r, http.StatusServiceUnavailable, "service_unavailable")
+				return
+			}
+			i18n.WriteError(w, r, http.StatusInternalServerError, "verify_credentials_failed")
+			return
+		}
+		ctx := WithPrincipal(r.Context(), p)
+		// Язык из профиля пользователя важнее Accept-Language, который браузер шлёт сам
+		if lang := preferredLang(p.Locale); lang != "" {
+			ctx = i18n.WithLang(ctx, lang)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// preferredLang сводит claim locale (ru, ru-RU, ru_RU) к поддерживаемому языку; пустая строка — не поддерживается
+func preferredLang(locale string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(locale, "_", "-")), "-")
+	if !i18n.Supported(primary) {
+		return ""
+	}
+	return primary
+}
+
+// unauthorized отвечает 401 в формате ошибок API
+func unauthorized(w http.ResponseWriter, r *http.Request, code string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="onlineSubscriptions"`)
+	w.Header().Add("WWW-Authenticate", `ApiKey realm="onlineSubscriptions"`)
+	i18n.WriteError(w, r, http.StatusUnauthorized, code)
+}
+
+// stringList приводит claim к списку строк: поддерживаются массив и строка через пробел
+func stringList(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return strings.Fields(t)
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
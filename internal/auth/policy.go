@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+// Access доступ роли к действию
+type Access int
+
+const (
+	// AccessNone действие запрещено
+	AccessNone Access = iota
+	// AccessOwn действие разрешено только над подписками самого пользователя
+	AccessOwn
+	// AccessAll действие разрешено над подписками всех пользователей
+	AccessAll
+)
+
+func (a Access) String() string {
+	switch a {
+	case AccessOwn:
+		return "own"
+	case AccessAll:
+		return "all"
+	}
+	return "none"
+}
+
+// UnmarshalText разбирает значения none, own и all из файла политики
+func (a *Access) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "none":
+		*a = AccessNone
+	case "own":
+		*a = AccessOwn
+	case "all":
+		*a = AccessAll
+	default:
+		return fmt.Errorf("access must be one of none, own, all, got %q", text)
+	}
+	return nil
+}
+
+// MarshalText нужен для вывода политики в том же формате, в котором она задаётся
+func (a Access) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// Policy сопоставляет ролям доступ к действиям
+type Policy struct {
+	// DefaultRole роль пользователя, в токене которого нет ни одной известной политике роли
+	DefaultRole string `yaml:"default_role"`
+	// Roles для каждой роли — доступ к действиям; не перечисленные действия запрещены
+	Roles map[string]map[string]Access `yaml:"roles"`
+}
+
+// DefaultPolicy политика по умолчанию. Пользователь без ролей получает editor,
+// то есть, как и до появления ролей, работает со своими подписками.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		DefaultRole: "editor",
+		Roles: map[string]map[string]Access{
+			"viewer":  {ActionRead: AccessOwn, ActionReports: AccessOwn},
+			"editor":  {ActionRead: AccessOwn, ActionWrite: AccessOwn, ActionReports: AccessOwn},
+			"finance": {ActionRead: AccessAll, ActionReports: AccessAll},
+			"admin": {
+				ActionRead:    AccessAll,
+				ActionWrite:   AccessAll,
+				ActionReports: AccessAll,
+				ActionAdmin:   AccessAll,
+			},
+		},
+	}
+}
+
+// LoadPolicy читает политику из YAML-файла и проверяет её
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Validate проверяет, что политика ссылается только на известные действия и роли
+func (p *Policy) Validate() error {
+	var errs []error
+	if len(p.Roles) == 0 {
+		errs = append(errs, errors.New("at least one role is required"))
+	}
+	for role, actions := range p.Roles {
+		for action, access := range actions {
+			if !slices.Contains(Actions, action) {
+				errs = append(errs, fmt.Errorf("role %s: unknown action %q", role, action))
+			}
+			// API-ключи не принадлежат пользователям, поэтому «свои» ключи не имеют смысла
+			if action == ActionAdmin && access == AccessOwn {
+				errs = append(errs, fmt.Errorf("role %s: action admin supports only none or all", role))
+			}
+		}
+	}
+	if p.DefaultRole != "" {
+		if _, ok := p.Roles[p.DefaultRole]; !ok {
+			errs = append(errs, fmt.Errorf("default_role %q is not defined", p.DefaultRole))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Decide возвращает доступ вызывающей стороны к действию.
+// Сервис с API-ключом получает доступ ко всем пользователям для действий из своих областей,
+// область admin включает все остальные. Пользователь получает наибольший доступ среди своих ролей.
+func (p *Policy) Decide(pr *Principal, action string) Access {
+	if pr.IsService() {
+		if slices.Contains(pr.Scopes, action) || slices.Contains(pr.Scopes, ActionAdmin) {
+			return AccessAll
+		}
+		return AccessNone
+	}
+	best := AccessNone
+	known := false
+	for _, role := range pr.Roles {
+		actions, ok := p.Roles[role]
+		if !ok {
+			continue
+		}
+		known = true
+		best = max(best, actions[action])
+	}
+	if !known && p.DefaultRole != "" {
+		best = p.Roles[p.DefaultRole][action]
+	}
+	return best
+}
+
+// Require пропускает запрос, только если политика разрешает вызывающей стороне действие action,
+// и сохраняет выданный доступ в контексте, чтобы обработчики ограничили выборку.
+// Запросы без Principal (аутентификация отключена) пропускаются с полным доступом.
+func (p *Policy) Require(action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			access := AccessAll
+			if pr, ok := FromContext(r.Context()); ok {
+				access = p.Decide(pr, action)
+			}
+			if access == AccessNone {
+				i18n.WriteError(w, r, http.StatusForbidden, "action_forbidden", action)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithAccess(r.Context(), access)))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDecide(t *testing.T) {
+	policy := DefaultPolicy()
+	user := func(roles ...string) *Principal {
+		return &Principal{UserID: uuid.New(), Roles: roles}
+	}
+	service := func(scopes ...string) *Principal {
+		return &Principal{KeyID: uuid.New(), Scopes: scopes}
+	}
+	tests := []struct {
+		name      string
+		principal *Principal
+		action    string
+		want      Access
+	}{
+		{"viewer reads own", user("viewer"), ActionRead, AccessOwn},
+		{"viewer cannot write", user("viewer"), ActionWrite, AccessNone},
+		{"editor writes own", user("editor"), ActionWrite, AccessOwn},
+		{"editor is not admin", user("editor"), ActionAdmin, AccessNone},
+		{"finance reads all", user("finance"), ActionRead, AccessAll},
+		{"finance cannot write", user("finance"), ActionWrite, AccessNone},
+		{"admin manages keys", user("admin"), ActionAdmin, AccessAll},
+		{"highest access among roles", user("viewer", "finance"), ActionReports, AccessAll},
+		{"roles combine per action", user("finance", "editor"), ActionWrite, AccessOwn},
+		{"no roles fall back to default", user(), ActionWrite, AccessOwn},
+		{"unknown roles fall back to default", user("intern"), ActionRead, AccessOwn},
+		{"known role ignores default", user("intern", "viewer"), ActionWrite, AccessNone},
+		{"service within scope", service(ActionRead), ActionRead, AccessAll},
+		{"service outside scope", service(ActionRead), ActionWrite, AccessNone},
+		{"service admin scope covers everything", service(ActionAdmin), ActionReports, AccessAll},
+		{"service without scopes", service(), ActionRead, AccessNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Decide(tt.principal, tt.action); got != tt.want {
+				t.Errorf("Decide(%v, %s) = %s, want %s", tt.principal.Roles, tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	policy := DefaultPolicy()
+	tests := []struct {
+		name       string
+		principal  *Principal
+		action     string
+		wantStatus int
+		wantAccess Access
+	}{
+		{"unauthenticated passes with full access", nil, ActionAdmin, http.StatusOK, AccessAll},
+		{"allowed action", &Principal{Roles: []string{"editor"}}, ActionWrite, http.StatusOK, AccessOwn},
+		{"forbidden action", &Principal{Roles: []string{"viewer"}}, ActionWrite, http.StatusForbidden, AccessNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAccess Access
+			handler := policy.Require(tt.action)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAccess = AccessFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotAccess != tt.wantAccess {
+				t.Errorf("access = %s, want %s", gotAccess, tt.wantAccess)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{"valid", "default_role: reader\nroles:\n  reader:\n    read: all\n", false},
+		{"no roles", "default_role: reader\n", true},
+		{"unknown action", "roles:\n  reader:\n    export: all\n", true},
+		{"own admin", "roles:\n  owner:\n    admin: own\n", true},
+		{"undefined default role", "default_role: ghost\nroles:\n  reader:\n    read: own\n", true},
+		{"bad access", "roles:\n  reader:\n    read: some\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadPolicy(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
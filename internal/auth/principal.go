@@ -0,0 +1,67 @@
+// Package auth проверяет учётные данные запросов и хранит сведения о вызывающей стороне в контексте.
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Действия, на которые политика выдаёт доступ. Они же служат областями действия API-ключей.
+const (
+	ActionRead    = "read"
+	ActionWrite   = "write"
+	ActionReports = "reports"
+	// ActionAdmin управление API-ключами
+	ActionAdmin = "admin"
+)
+
+// Actions все известные действия
+var Actions = []string{ActionRead, ActionWrite, ActionReports, ActionAdmin}
+
+// Principal аутентифицированная вызывающая сторона: пользователь с JWT или сервис с API-ключом
+type Principal struct {
+	// Subject исходное значение claim sub, для API-ключа — "apikey:<id>"
+	Subject string
+	UserID  uuid.UUID
+	Roles   []string
+	// OrgID организация вызывающей стороны; uuid.Nil, если токен не привязан к организации
+	OrgID uuid.UUID
+	// KeyID идентификатор API-ключа, uuid.Nil для пользователей
+	KeyID  uuid.UUID
+	Scopes []string
+	// Locale предпочитаемый язык пользователя из токена, например "ru"; пустой, если не задан
+	Locale string
+}
+
+// IsService сообщает, что запрос выполнен по API-ключу. Сервисы не привязаны к пользователю
+// и видят подписки всех пользователей в пределах своих областей действия.
+func (p *Principal) IsService() bool {
+	return p.KeyID != uuid.Nil
+}
+
+type ctxKey struct{}
+
+type accessKey struct{}
+
+// WithPrincipal сохраняет вызывающую сторону в контексте
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext возвращает вызывающую сторону из контекста, если запрос аутентифицирован
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*Principal)
+	return p, ok
+}
+
+// WithAccess сохраняет в контексте доступ, выданный политикой на действие маршрута
+func WithAccess(ctx context.Context, a Access) context.Context {
+	return context.WithValue(ctx, accessKey{}, a)
+}
+
+// AccessFromContext возвращает доступ, выданный политикой; AccessNone, если решение не принималось
+func AccessFromContext(ctx context.Context) Access {
+	a, _ := ctx.Value(accessKey{}).(Access)
+	return a
+}
@@ -0,0 +1,116 @@
+// Package billing раскладывает подписки в график конкретных списаний.
+package billing
+
+import (
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+)
+
+// Charge одно списание по подписке
+type Charge struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ServiceName    string    `json:"service_name"`
+	Date           time.Time `json:"date"`
+	Amount         int       `json:"amount"`
+}
+
+// Charges возвращает списания по подписке с датами в интервале [from, to].
+// Учитываются периодичность, пробный период, паузы, запланированные изменения цены
+// (sub.Pauses и sub.PriceChanges должны быть загружены) и дата окончания.
+func Charges(sub model.Subscription, from, to time.Time) []Charge {
+	var charges []Charge
+	ForEachDate(sub, to, func(date time.Time) {
+		if date.Before(from) || IsPaused(sub, date) {
+			return
+		}
+		charges = append(charges, Charge{
+			SubscriptionID: sub.ID,
+			UserID:         sub.UserID,
+			ServiceName:    sub.ServiceName,
+			Date:           date,
+			Amount:         PriceAt(sub, date),
+		})
+	})
+	return charges
+}
+
+// ForEachDate вызывает fn для каждой даты списания по расписанию подписки не позже to,
+// начиная с первого платного периода. Паузы не учитываются.
+func ForEachDate(sub model.Subscription, to time.Time, fn func(time.Time)) {
+	step := periodMonths(sub)
+	last := to
+	if sub.EndDate != nil && sub.EndDate.Before(last) {
+		last = *sub.EndDate
+	}
+	for i := 0; ; i++ {
+		date := sub.StartDate.AddDate(0, i*step, 0)
+		if date.After(last) {
+			return
+		}
+		if sub.TrialEndDate != nil && date.Before(*sub.TrialEndDate) {
+			continue
+		}
+		fn(date)
+	}
+}
+
+// FirstChargeDate возвращает дату первого платного списания по расписанию
+func FirstChargeDate(sub model.Subscription) time.Time {
+	step := periodMonths(sub)
+	date := sub.StartDate
+	for sub.TrialEndDate != nil && date.Before(*sub.TrialEndDate) {
+		date = date.AddDate(0, step, 0)
+	}
+	return date
+}
+
+// NextChargeDate возвращает первую дату списания по расписанию не раньше from.
+// Паузы и дата окончания не учитываются.
+func NextChargeDate(sub model.Subscription, from time.Time) time.Time {
+	step := periodMonths(sub)
+	for i := 0; ; i++ {
+		date := sub.StartDate.AddDate(0, i*step, 0)
+		if date.Before(from) || (sub.TrialEndDate != nil && date.Before(*sub.TrialEndDate)) {
+			continue
+		}
+		return date
+	}
+}
+
+// PriceAt возвращает цену списания на дату с учётом последнего вступившего в силу изменения цены
+func PriceAt(sub model.Subscription, date time.Time) int {
+	price := sub.Price
+	var effective time.Time
+	for _, c := range sub.PriceChanges {
+		if c.EffectiveDate.After(date) || c.EffectiveDate.Before(effective) {
+			continue
+		}
+		price = c.Price
+		effective = c.EffectiveDate
+	}
+	return price
+}
+
+// IsPaused сообщает, попадает ли дата в одну из пауз подписки
+func IsPaused(sub model.Subscription, date time.Time) bool {
+	for _, p := range sub.Pauses {
+		if date.Before(p.StartDate) {
+			continue
+		}
+		if p.EndDate == nil || !date.After(*p.EndDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// periodMonths возвращает шаг расписания; неизвестный период считается ежемесячным
+func periodMonths(sub model.Subscription) int {
+	if m := sub.BillingPeriod.Months(); m > 0 {
+		return m
+	}
+	return 1
+}
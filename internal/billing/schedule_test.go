@@ -0,0 +1,173 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+)
+
+func month(y int, m time.Month) time.Time {
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestFirstChargeDate(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  model.Subscription
+		want time.Time
+	}{
+		{
+			name: "no trial",
+			sub:  model.Subscription{BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1)},
+			want: month(2025, 1),
+		},
+		{
+			name: "monthly trial",
+			sub:  model.Subscription{BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1), TrialEndDate: ptr(month(2025, 3))},
+			want: month(2025, 3),
+		},
+		{
+			name: "quarterly trial ends mid-period",
+			sub:  model.Subscription{BillingPeriod: model.BillingQuarterly, StartDate: month(2025, 1), TrialEndDate: ptr(month(2025, 2))},
+			want: month(2025, 4),
+		},
+		{
+			name: "unknown period is monthly",
+			sub:  model.Subscription{StartDate: month(2025, 1), TrialEndDate: ptr(month(2025, 2))},
+			want: month(2025, 2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FirstChargeDate(tt.sub); !got.Equal(tt.want) {
+				t.Errorf("FirstChargeDate() = %s, want %s", got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestCharges(t *testing.T) {
+	type charge struct {
+		date   time.Time
+		amount int
+	}
+	tests := []struct {
+		name     string
+		sub      model.Subscription
+		from, to time.Time
+		want     []charge
+	}{
+		{
+			name: "monthly",
+			sub:  model.Subscription{Price: 100, BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1)},
+			from: month(2025, 1), to: month(2025, 3),
+			want: []charge{{month(2025, 1), 100}, {month(2025, 2), 100}, {month(2025, 3), 100}},
+		},
+		{
+			name: "window starts after subscription",
+			sub:  model.Subscription{Price: 100, BillingPeriod: model.BillingQuarterly, StartDate: month(2024, 11)},
+			from: month(2025, 1), to: month(2025, 12),
+			want: []charge{{month(2025, 2), 100}, {month(2025, 5), 100}, {month(2025, 8), 100}, {month(2025, 11), 100}},
+		},
+		{
+			name: "yearly",
+			sub:  model.Subscription{Price: 1200, BillingPeriod: model.BillingYearly, StartDate: month(2024, 6)},
+			from: month(2025, 1), to: month(2026, 12),
+			want: []charge{{month(2025, 6), 1200}, {month(2026, 6), 1200}},
+		},
+		{
+			name: "trial",
+			sub:  model.Subscription{Price: 100, BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1), TrialEndDate: ptr(month(2025, 3))},
+			from: month(2025, 1), to: month(2025, 4),
+			want: []charge{{month(2025, 3), 100}, {month(2025, 4), 100}},
+		},
+		{
+			name: "end date is the last charged month",
+			sub:  model.Subscription{Price: 100, BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1), EndDate: ptr(month(2025, 2))},
+			from: month(2025, 1), to: month(2025, 6),
+			want: []charge{{month(2025, 1), 100}, {month(2025, 2), 100}},
+		},
+		{
+			name: "bounded pause",
+			sub: model.Subscription{
+				Price: 100, BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1),
+				Pauses: []model.SubscriptionPause{{StartDate: month(2025, 2), EndDate: ptr(month(2025, 3))}},
+			},
+			from: month(2025, 1), to: month(2025, 4),
+			want: []charge{{month(2025, 1), 100}, {month(2025, 4), 100}},
+		},
+		{
+			name: "open-ended pause",
+			sub: model.Subscription{
+				Price: 100, BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1),
+				Pauses: []model.SubscriptionPause{{StartDate: month(2025, 2)}},
+			},
+			from: month(2025, 1), to: month(2025, 6),
+			want: []charge{{month(2025, 1), 100}},
+		},
+		{
+			name: "price changes apply from their effective date",
+			sub: model.Subscription{
+				Price: 100, BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1),
+				PriceChanges: []model.PriceChange{
+					{EffectiveDate: month(2025, 4), Price: 300},
+					{EffectiveDate: month(2025, 2), Price: 200},
+				},
+			},
+			from: month(2025, 1), to: month(2025, 4),
+			want: []charge{{month(2025, 1), 100}, {month(2025, 2), 200}, {month(2025, 3), 200}, {month(2025, 4), 300}},
+		},
+		{
+			name: "empty window",
+			sub:  model.Subscription{Price: 100, BillingPeriod: model.BillingMonthly, StartDate: month(2026, 1)},
+			from: month(2025, 1), to: month(2025, 12),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Charges(tt.sub, tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Charges() returned %d charges, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, c := range got {
+				if !c.Date.Equal(tt.want[i].date) || c.Amount != tt.want[i].amount {
+					t.Errorf("charge %d = %s %d, want %s %d", i,
+						c.Date.Format(time.DateOnly), c.Amount, tt.want[i].date.Format(time.DateOnly), tt.want[i].amount)
+				}
+			}
+		})
+	}
+}
+
+func TestPriceAt(t *testing.T) {
+	sub := model.Subscription{
+		Price: 100,
+		PriceChanges: []model.PriceChange{
+			{EffectiveDate: month(2025, 6), Price: 300},
+			{EffectiveDate: month(2025, 3), Price: 200},
+		},
+	}
+	tests := []struct {
+		name string
+		date time.Time
+		want int
+	}{
+		{"before any change", month(2025, 2), 100},
+		{"on the effective date", month(2025, 3), 200},
+		{"between changes", month(2025, 5), 200},
+		{"latest change wins regardless of order", month(2025, 7), 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PriceAt(sub, tt.date); got != tt.want {
+				t.Errorf("PriceAt(%s) = %d, want %d", tt.date.Format(time.DateOnly), got, tt.want)
+			}
+		})
+	}
+}
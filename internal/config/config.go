@@ -1,50 +1,670 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// Config конфигурация сервиса.
+// Источники применяются по порядку: значения по умолчанию, файл конфигурации (YAML/TOML),
+// переменные окружения (в том числе из необязательного .env) и флаги командной строки.
 type Config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
+	HTTP      HTTPConfig      `yaml:"http" toml:"http"`
+	DB        DBConfig        `yaml:"db" toml:"db"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	CORS      CORSConfig      `yaml:"cors" toml:"cors"`
+	// Compression сжатие ответов gzip и br
+	Compression CompressionConfig `yaml:"compression" toml:"compression"`
+	Security    SecurityConfig    `yaml:"security" toml:"security"`
+	I18n        I18nConfig        `yaml:"i18n" toml:"i18n"`
+	Features    FeaturesConfig    `yaml:"features" toml:"features"`
+	Seed        SeedConfig        `yaml:"seed" toml:"seed"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks" toml:"webhooks"`
 }
 
-func LoadConfig() (*Config, error) {
-	// Проверяем, есть ли файл
-	if _, err := os.Stat(".env"); err != nil {
-		return nil, fmt.Errorf(".env file not found: %w", err)
+// HTTPConfig настройки HTTP-сервера
+type HTTPConfig struct {
+	Addr              string        `yaml:"addr" toml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// ShutdownTimeout время на завершение текущих запросов и фоновых задач при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// ShutdownDelay пауза между отказом /readyz и закрытием приёма соединений
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay"`
+	// MaxBodySize наибольший размер тела запроса в байтах; больше — ответ 413
+	MaxBodySize int `yaml:"max_body_size" toml:"max_body_size"`
+}
+
+// DBConfig параметры подключения и пула соединений PostgreSQL
+type DBConfig struct {
+	Host            string        `yaml:"host" toml:"host"`
+	Port            int           `yaml:"port" toml:"port"`
+	User            string        `yaml:"user" toml:"user"`
+	Password        string        `yaml:"password" toml:"password"`
+	Name            string        `yaml:"name" toml:"name"`
+	SSLMode         string        `yaml:"sslmode" toml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+}
+
+// LogConfig настройки журналирования
+type LogConfig struct {
+	// Level debug, info, warn или error
+	Level string `yaml:"level" toml:"level"`
+	// Format text или json
+	Format string `yaml:"format" toml:"format"`
+}
+
+// TracingConfig экспорт трасс OpenTelemetry
+type TracingConfig struct {
+	// Exporter none, otlp (OTLP/HTTP), stdout или file
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint адрес коллектора OTLP/HTTP, например otel-collector:4318
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	Insecure bool   `yaml:"insecure" toml:"insecure"`
+	// File путь к файлу для экспортёра file
+	File        string  `yaml:"file" toml:"file"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// AuthConfig проверка JWT bearer-токенов
+type AuthConfig struct {
+	// Enabled без аутентификации все запросы выполняются с правами администратора
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// HS256Secret общий секрет для токенов HS256
+	HS256Secret string `yaml:"hs256_secret" toml:"hs256_secret"`
+	// JWKSFile и JWKSURL источник открытых ключей для токенов RS256 (задаётся один из них)
+	JWKSFile    string        `yaml:"jwks_file" toml:"jwks_file"`
+	JWKSURL     string        `yaml:"jwks_url" toml:"jwks_url"`
+	JWKSRefresh time.Duration `yaml:"jwks_refresh" toml:"jwks_refresh"`
+	Issuer      string        `yaml:"issuer" toml:"issuer"`
+	Audience    string        `yaml:"audience" toml:"audience"`
+	// RolesClaim claim со списком ролей (viewer, editor, finance, admin или роли из PolicyFile)
+	RolesClaim string `yaml:"roles_claim" toml:"roles_claim"`
+	// OrgClaim claim с UUID организации пользователя
+	OrgClaim string `yaml:"org_claim" toml:"org_claim"`
+	// LocaleClaim claim с предпочитаемым языком пользователя; важнее заголовка Accept-Language
+	LocaleClaim string `yaml:"locale_claim" toml:"locale_claim"`
+	// PolicyFile YAML-файл с политикой доступа ролей; без него действует встроенная политика
+	PolicyFile string `yaml:"policy_file" toml:"policy_file"`
+}
+
+// RateLimitConfig ограничение частоты запросов по алгоритму token bucket.
+// Лимиты считаются отдельно для каждого клиента (API-ключ, пользователь или IP) и класса эндпоинтов.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Default чтение и прочие эндпоинты
+	Default RateLimitRule `yaml:"default" toml:"default"`
+	// Write создание, изменение и удаление
+	Write RateLimitRule `yaml:"write" toml:"write"`
+	// Expensive тяжёлые выборки: полный список подписок, сводка, прогноз, списания
+	Expensive RateLimitRule `yaml:"expensive" toml:"expensive"`
+	// IP общий лимит по IP-адресу, проверяемый до аутентификации: запросы с неверными учётными данными
+	// и проверки API-ключей в БД тоже расходуют его
+	IP RateLimitRule `yaml:"ip" toml:"ip"`
+	// TrustForwardedFor брать IP клиента из последнего адреса X-Forwarded-For, который дописал прокси;
+	// включать только за одним доверенным прокси
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" toml:"trust_forwarded_for"`
+}
+
+// RateLimitRule скорость пополнения и ёмкость корзины токенов
+type RateLimitRule struct {
+	// PerMinute запросов в минуту в среднем
+	PerMinute int `yaml:"per_minute" toml:"per_minute"`
+	// Burst сколько запросов можно сделать подряд
+	Burst int `yaml:"burst" toml:"burst"`
+}
+
+// CORSConfig доступ к API из браузера с других источников
+type CORSConfig struct {
+	// AllowedOrigins разрешённые источники (https://app.example.com) или "*"; пустой список отключает CORS
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods []string `yaml:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers" toml:"allowed_headers"`
+	// AllowCredentials разрешает cookies и заголовок Authorization; несовместимо с "*" в AllowedOrigins
+	AllowCredentials bool `yaml:"allow_credentials" toml:"allow_credentials"`
+	// MaxAge сколько браузер может кэшировать ответ на preflight-запрос
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// CompressionConfig сжатие ответов
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// MinSize ответы меньше этого размера в байтах отдаются без сжатия
+	MinSize int `yaml:"min_size" toml:"min_size"`
+}
+
+// SecurityConfig стандартные заголовки безопасности
+type SecurityConfig struct {
+	Headers bool `yaml:"headers" toml:"headers"`
+	// HSTSMaxAge срок Strict-Transport-Security; 0 не отправляет заголовок (TLS завершается не в сервисе)
+	HSTSMaxAge time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age"`
+}
+
+// I18nConfig язык сообщений API
+type I18nConfig struct {
+	// DefaultLanguage en или ru; используется, если ни токен, ни Accept-Language не указывают поддерживаемый язык
+	DefaultLanguage string `yaml:"default_language" toml:"default_language"`
+}
+
+// FeaturesConfig включение необязательных возможностей
+type FeaturesConfig struct {
+	// Export выгрузка списков в CSV/XLSX/NDJSON по заголовку Accept
+	Export bool `yaml:"export" toml:"export"`
+	// Metrics эндпоинт /metrics для Prometheus
+	Metrics bool `yaml:"metrics" toml:"metrics"`
+	// MetricsAddr отдельный адрес для /metrics: метрики показывают расходы всех организаций,
+	// поэтому они не публикуются на адресе API и должны быть доступны только из внутренней сети
+	MetricsAddr string `yaml:"metrics_addr" toml:"metrics_addr"`
+	// Calendar лента продлений в формате iCalendar, требует CalendarSecret
+	Calendar       bool   `yaml:"calendar" toml:"calendar"`
+	CalendarSecret string `yaml:"calendar_secret" toml:"calendar_secret"`
+}
+
+// SeedConfig демонстрационные данные, создаваемые при старте в организации по умолчанию
+type SeedConfig struct {
+	// Users число сгенерированных пользователей; 0 — ничего не создавать
+	Users int `yaml:"users" toml:"users"`
+	// Random начальное значение генератора; с тем же значением повторный старт данных не добавляет
+	Random int `yaml:"random" toml:"random"`
+}
+
+// WebhooksConfig доставка событий подписок на адреса клиентов
+type WebhooksConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Timeout ожидание ответа на одну попытку доставки
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// MaxAttempts после стольких неудачных попыток доставка попадает в список недоставленных
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// BackoffBase задержка перед первым повтором; каждая следующая вдвое больше, но не больше BackoffMax
+	BackoffBase time.Duration `yaml:"backoff_base" toml:"backoff_base"`
+	BackoffMax  time.Duration `yaml:"backoff_max" toml:"backoff_max"`
+	// PollInterval как часто проверяются доставки, ожидающие отправки
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// ScanInterval как часто ищутся предстоящие продления и закончившиеся подписки
+	ScanInterval time.Duration `yaml:"scan_interval" toml:"scan_interval"`
+	// RenewalNotice за сколько до списания отправляется subscription.renewal_upcoming
+	RenewalNotice time.Duration `yaml:"renewal_notice" toml:"renewal_notice"`
+	// AllowPrivate разрешает адреса loopback, частных сетей и link-local; только для локальной разработки
+	AllowPrivate bool `yaml:"allow_private" toml:"allow_private"`
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			MaxBodySize:       1 << 20,
+		},
+		DB: DBConfig{
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			File:        "traces.json",
+			ServiceName: "online-subscriptions",
+			SampleRatio: 1,
+		},
+		Auth: AuthConfig{
+			Enabled:     true,
+			JWKSRefresh: 15 * time.Minute,
+			RolesClaim:  "roles",
+			OrgClaim:    "org_id",
+			LocaleClaim: "locale",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   true,
+			Default:   RateLimitRule{PerMinute: 600, Burst: 100},
+			Write:     RateLimitRule{PerMinute: 120, Burst: 20},
+			Expensive: RateLimitRule{PerMinute: 30, Burst: 5},
+			IP:        RateLimitRule{PerMinute: 1200, Burst: 200},
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "Accept", "X-Request-ID", "X-Org-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Compression: CompressionConfig{Enabled: true, MinSize: 1024},
+		Security:    SecurityConfig{Headers: true},
+		I18n:        I18nConfig{DefaultLanguage: "en"},
+		Features: FeaturesConfig{
+			Export:      true,
+			Metrics:     true,
+			MetricsAddr: ":9090",
+		},
+		Seed: SeedConfig{Random: 1},
+		Webhooks: WebhooksConfig{
+			Timeout:       10 * time.Second,
+			MaxAttempts:   8,
+			BackoffBase:   30 * time.Second,
+			BackoffMax:    time.Hour,
+			PollInterval:  5 * time.Second,
+			ScanInterval:  time.Hour,
+			RenewalNotice: 72 * time.Hour,
+		},
 	}
-	// Загружаем .env
-	err := godotenv.Load(".env")
-	if err != nil {
+}
 
-		return nil, fmt.Errorf("error loading .env: %w", err)
+// setting описывает параметр, который можно задать переменной окружения и флагом
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	secret bool
+	ptr    any
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "http-addr", env: "HTTP_ADDR", usage: "HTTP listen address", ptr: &c.HTTP.Addr},
+		{key: "http-read-timeout", env: "HTTP_READ_TIMEOUT", usage: "max duration for reading a request", ptr: &c.HTTP.ReadTimeout},
+		{key: "http-read-header-timeout", env: "HTTP_READ_HEADER_TIMEOUT", usage: "max duration for reading request headers", ptr: &c.HTTP.ReadHeaderTimeout},
+		{key: "http-write-timeout", env: "HTTP_WRITE_TIMEOUT", usage: "max duration for writing a response", ptr: &c.HTTP.WriteTimeout},
+		{key: "http-idle-timeout", env: "HTTP_IDLE_TIMEOUT", usage: "max keep-alive idle time", ptr: &c.HTTP.IdleTimeout},
+		{key: "http-shutdown-timeout", env: "HTTP_SHUTDOWN_TIMEOUT", usage: "drain period on shutdown", ptr: &c.HTTP.ShutdownTimeout},
+		{key: "http-shutdown-delay", env: "HTTP_SHUTDOWN_DELAY", usage: "delay between failing readiness and closing listeners", ptr: &c.HTTP.ShutdownDelay},
+		{key: "http-max-body-size", env: "HTTP_MAX_BODY_SIZE", usage: "max request body size in bytes", ptr: &c.HTTP.MaxBodySize},
+		{key: "db-host", env: "DB_HOST", usage: "database host", ptr: &c.DB.Host},
+		{key: "db-port", env: "DB_PORT", usage: "database port", ptr: &c.DB.Port},
+		{key: "db-user", env: "DB_USER", usage: "database user", ptr: &c.DB.User},
+		{key: "db-password", env: "DB_PASSWORD", usage: "database password", secret: true, ptr: &c.DB.Password},
+		{key: "db-name", env: "DB_NAME", usage: "database name", ptr: &c.DB.Name},
+		{key: "db-sslmode", env: "DB_SSLMODE", usage: "database sslmode", ptr: &c.DB.SSLMode},
+		{key: "db-max-open-conns", env: "DB_MAX_OPEN_CONNS", usage: "max open database connections (0 = unlimited)", ptr: &c.DB.MaxOpenConns},
+		{key: "db-max-idle-conns", env: "DB_MAX_IDLE_CONNS", usage: "max idle database connections", ptr: &c.DB.MaxIdleConns},
+		{key: "db-conn-max-lifetime", env: "DB_CONN_MAX_LIFETIME", usage: "max lifetime of a database connection", ptr: &c.DB.ConnMaxLifetime},
+		{key: "db-conn-max-idle-time", env: "DB_CONN_MAX_IDLE_TIME", usage: "max idle time of a database connection", ptr: &c.DB.ConnMaxIdleTime},
+		{key: "log-level", env: "LOG_LEVEL", usage: "log level: debug, info, warn, error", ptr: &c.Log.Level},
+		{key: "log-format", env: "LOG_FORMAT", usage: "log format: text, json", ptr: &c.Log.Format},
+		{key: "tracing-exporter", env: "TRACING_EXPORTER", usage: "trace exporter: none, otlp, stdout, file", ptr: &c.Tracing.Exporter},
+		{key: "tracing-endpoint", env: "TRACING_ENDPOINT", usage: "OTLP/HTTP collector endpoint", ptr: &c.Tracing.Endpoint},
+		{key: "tracing-insecure", env: "TRACING_INSECURE", usage: "use plain HTTP for the OTLP exporter", ptr: &c.Tracing.Insecure},
+		{key: "tracing-file", env: "TRACING_FILE", usage: "output file for the file exporter", ptr: &c.Tracing.File},
+		{key: "tracing-service-name", env: "TRACING_SERVICE_NAME", usage: "service.name resource attribute", ptr: &c.Tracing.ServiceName},
+		{key: "tracing-sample-ratio", env: "TRACING_SAMPLE_RATIO", usage: "fraction of new traces to sample, 0..1", ptr: &c.Tracing.SampleRatio},
+		{key: "auth-enabled", env: "AUTH_ENABLED", usage: "require JWT bearer tokens or API keys; false gives every request admin access, for local development only", ptr: &c.Auth.Enabled},
+		{key: "auth-hs256-secret", env: "AUTH_HS256_SECRET", usage: "shared secret for HS256 tokens", secret: true, ptr: &c.Auth.HS256Secret},
+		{key: "auth-jwks-file", env: "AUTH_JWKS_FILE", usage: "local JWKS file with RS256 keys", ptr: &c.Auth.JWKSFile},
+		{key: "auth-jwks-url", env: "AUTH_JWKS_URL", usage: "JWKS URL with RS256 keys", ptr: &c.Auth.JWKSURL},
+		{key: "auth-jwks-refresh", env: "AUTH_JWKS_REFRESH", usage: "JWKS reload interval", ptr: &c.Auth.JWKSRefresh},
+		{key: "auth-issuer", env: "AUTH_ISSUER", usage: "required iss claim", ptr: &c.Auth.Issuer},
+		{key: "auth-audience", env: "AUTH_AUDIENCE", usage: "required aud claim", ptr: &c.Auth.Audience},
+		{key: "auth-roles-claim", env: "AUTH_ROLES_CLAIM", usage: "claim holding caller roles", ptr: &c.Auth.RolesClaim},
+		{key: "auth-org-claim", env: "AUTH_ORG_CLAIM", usage: "claim holding caller organization UUID", ptr: &c.Auth.OrgClaim},
+		{key: "auth-locale-claim", env: "AUTH_LOCALE_CLAIM", usage: "claim holding caller preferred language", ptr: &c.Auth.LocaleClaim},
+		{key: "auth-policy-file", env: "AUTH_POLICY_FILE", usage: "YAML file with role access policy", ptr: &c.Auth.PolicyFile},
+		{key: "rate-limit-enabled", env: "RATE_LIMIT_ENABLED", usage: "limit request rate per client", ptr: &c.RateLimit.Enabled},
+		{key: "rate-limit-default-per-minute", env: "RATE_LIMIT_DEFAULT_PER_MINUTE", usage: "requests per minute for regular endpoints", ptr: &c.RateLimit.Default.PerMinute},
+		{key: "rate-limit-default-burst", env: "RATE_LIMIT_DEFAULT_BURST", usage: "burst for regular endpoints", ptr: &c.RateLimit.Default.Burst},
+		{key: "rate-limit-write-per-minute", env: "RATE_LIMIT_WRITE_PER_MINUTE", usage: "requests per minute for write endpoints", ptr: &c.RateLimit.Write.PerMinute},
+		{key: "rate-limit-write-burst", env: "RATE_LIMIT_WRITE_BURST", usage: "burst for write endpoints", ptr: &c.RateLimit.Write.Burst},
+		{key: "rate-limit-expensive-per-minute", env: "RATE_LIMIT_EXPENSIVE_PER_MINUTE", usage: "requests per minute for expensive endpoints", ptr: &c.RateLimit.Expensive.PerMinute},
+		{key: "rate-limit-expensive-burst", env: "RATE_LIMIT_EXPENSIVE_BURST", usage: "burst for expensive endpoints", ptr: &c.RateLimit.Expensive.Burst},
+		{key: "rate-limit-ip-per-minute", env: "RATE_LIMIT_IP_PER_MINUTE", usage: "requests per minute per client IP, checked before authentication", ptr: &c.RateLimit.IP.PerMinute},
+		{key: "rate-limit-ip-burst", env: "RATE_LIMIT_IP_BURST", usage: "burst per client IP, checked before authentication", ptr: &c.RateLimit.IP.Burst},
+		{key: "rate-limit-trust-forwarded-for", env: "RATE_LIMIT_TRUST_FORWARDED_FOR", usage: "take client IP from the last X-Forwarded-For address, appended by a trusted proxy", ptr: &c.RateLimit.TrustForwardedFor},
+		{key: "cors-allowed-origins", env: "CORS_ALLOWED_ORIGINS", usage: "comma-separated origins allowed to call the API, * for any", ptr: &c.CORS.AllowedOrigins},
+		{key: "cors-allowed-methods", env: "CORS_ALLOWED_METHODS", usage: "comma-separated methods allowed in CORS requests", ptr: &c.CORS.AllowedMethods},
+		{key: "cors-allowed-headers", env: "CORS_ALLOWED_HEADERS", usage: "comma-separated request headers allowed in CORS requests", ptr: &c.CORS.AllowedHeaders},
+		{key: "cors-allow-credentials", env: "CORS_ALLOW_CREDENTIALS", usage: "allow credentials in CORS requests", ptr: &c.CORS.AllowCredentials},
+		{key: "cors-max-age", env: "CORS_MAX_AGE", usage: "how long browsers may cache preflight responses", ptr: &c.CORS.MaxAge},
+		{key: "compression-enabled", env: "COMPRESSION_ENABLED", usage: "compress responses with gzip or br", ptr: &c.Compression.Enabled},
+		{key: "compression-min-size", env: "COMPRESSION_MIN_SIZE", usage: "minimum response size in bytes to compress", ptr: &c.Compression.MinSize},
+		{key: "security-headers", env: "SECURITY_HEADERS", usage: "send standard security headers", ptr: &c.Security.Headers},
+		{key: "security-hsts-max-age", env: "SECURITY_HSTS_MAX_AGE", usage: "Strict-Transport-Security max-age, 0 to disable", ptr: &c.Security.HSTSMaxAge},
+		{key: "i18n-default-language", env: "I18N_DEFAULT_LANGUAGE", usage: "default language of API messages: en, ru", ptr: &c.I18n.DefaultLanguage},
+		{key: "feature-export", env: "FEATURE_EXPORT", usage: "enable CSV/XLSX/NDJSON export", ptr: &c.Features.Export},
+		{key: "feature-metrics", env: "FEATURE_METRICS", usage: "expose Prometheus metrics on /metrics", ptr: &c.Features.Metrics},
+		{key: "metrics-addr", env: "METRICS_ADDR", usage: "internal listen address for /metrics, separate from the API", ptr: &c.Features.MetricsAddr},
+		{key: "feature-calendar", env: "FEATURE_CALENDAR", usage: "enable iCalendar renewals feed", ptr: &c.Features.Calendar},
+		{key: "calendar-secret", env: "CALENDAR_SECRET", usage: "secret for calendar feed tokens", secret: true, ptr: &c.Features.CalendarSecret},
+		{key: "webhooks-enabled", env: "WEBHOOKS_ENABLED", usage: "deliver subscription events to registered webhooks", ptr: &c.Webhooks.Enabled},
+		{key: "webhooks-timeout", env: "WEBHOOKS_TIMEOUT", usage: "timeout of one webhook delivery attempt", ptr: &c.Webhooks.Timeout},
+		{key: "webhooks-max-attempts", env: "WEBHOOKS_MAX_ATTEMPTS", usage: "attempts before a delivery is moved to the dead-letter list", ptr: &c.Webhooks.MaxAttempts},
+		{key: "webhooks-backoff-base", env: "WEBHOOKS_BACKOFF_BASE", usage: "delay before the first retry, doubled on each next one", ptr: &c.Webhooks.BackoffBase},
+		{key: "webhooks-backoff-max", env: "WEBHOOKS_BACKOFF_MAX", usage: "max delay between retries", ptr: &c.Webhooks.BackoffMax},
+		{key: "webhooks-poll-interval", env: "WEBHOOKS_POLL_INTERVAL", usage: "how often pending deliveries are sent", ptr: &c.Webhooks.PollInterval},
+		{key: "webhooks-scan-interval", env: "WEBHOOKS_SCAN_INTERVAL", usage: "how often upcoming renewals and ended subscriptions are looked up", ptr: &c.Webhooks.ScanInterval},
+		{key: "webhooks-renewal-notice", env: "WEBHOOKS_RENEWAL_NOTICE", usage: "how long before a charge subscription.renewal_upcoming is sent", ptr: &c.Webhooks.RenewalNotice},
+		{key: "webhooks-allow-private", env: "WEBHOOKS_ALLOW_PRIVATE", usage: "allow webhooks to loopback, private and link-local addresses, for local development only", ptr: &c.Webhooks.AllowPrivate},
+		{key: "seed-users", env: "SEED_USERS", usage: "generate demo subscriptions for this many users on startup, 0 to disable", ptr: &c.Seed.Users},
+		{key: "seed-random", env: "SEED_RANDOM", usage: "random seed of generated demo data", ptr: &c.Seed.Random},
+	}
+}
+
+// LoadConfig собирает конфигурацию из всех источников и проверяет её.
+// args — аргументы командной строки без имени программы.
+func LoadConfig(args []string) (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	fs := flag.NewFlagSet("onlineSubscriptions", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "path to YAML or TOML config file")
+	flagValues := make(map[string]*flagValue, len(settings))
+	for _, s := range settings {
+		_, isBool := s.ptr.(*bool)
+		flagValues[s.key] = &flagValue{isBool: isBool}
+		fs.Var(flagValues[s.key], s.key, s.usage+" (env "+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
-	log.Printf("Loading config from .env")
 
-	// Проверяем, что переменные не пустые
-	requiredVars := []string{"DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME"}
-	for _, varName := range requiredVars {
-		if os.Getenv(varName) == "" {
-			return nil, fmt.Errorf("missing required env variable: %s", varName)
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+		slog.Info("loading config file", "path", *configPath)
+	}
+
+	// .env необязателен: переменные могут прийти из окружения (например, из docker-compose)
+	if err := godotenv.Load(".env"); err == nil {
+		slog.Info("loading environment from .env")
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error loading .env: %w", err)
+	}
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(v); err != nil {
+				return nil, fmt.Errorf("invalid env variable %s: %w", s.env, err)
+			}
 		}
 	}
 
-	cfg := &Config{
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.key == f.Name && flagErr == nil {
+				if err := s.set(flagValues[s.key].value); err != nil {
+					flagErr = fmt.Errorf("invalid flag -%s: %w", s.key, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
 	}
 
-	log.Println("Configuration loaded successfully")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	slog.Info("configuration loaded successfully")
 	return cfg, nil
 }
+
+// loadFile накладывает значения из файла YAML или TOML поверх текущих
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		_, err = toml.Decode(string(data), c)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate проверяет согласованность конфигурации и возвращает все найденные ошибки
+func (c *Config) Validate() error {
+	var errs []error
+	if c.HTTP.Addr == "" {
+		errs = append(errs, errors.New("http-addr is required"))
+	}
+	if c.HTTP.ReadTimeout < 0 || c.HTTP.ReadHeaderTimeout < 0 || c.HTTP.WriteTimeout < 0 || c.HTTP.IdleTimeout < 0 || c.HTTP.ShutdownDelay < 0 {
+		errs = append(errs, errors.New("http timeouts must not be negative"))
+	}
+	if c.HTTP.MaxBodySize <= 0 {
+		errs = append(errs, errors.New("http-max-body-size must be positive"))
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("http-shutdown-timeout must be positive"))
+	}
+	for _, s := range []struct{ key, value string }{
+		{"db-host", c.DB.Host}, {"db-user", c.DB.User}, {"db-password", c.DB.Password}, {"db-name", c.DB.Name},
+	} {
+		if s.value == "" {
+			errs = append(errs, fmt.Errorf("missing required setting: %s", s.key))
+		}
+	}
+	if c.DB.Port < 1 || c.DB.Port > 65535 {
+		errs = append(errs, fmt.Errorf("db-port must be between 1 and 65535, got %d", c.DB.Port))
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database pool sizes must not be negative"))
+	}
+	if c.DB.ConnMaxLifetime < 0 || c.DB.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database connection lifetimes must not be negative"))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log-level must be one of debug, info, warn, error, got %q", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log-format must be text or json, got %q", c.Log.Format))
+	}
+	switch c.I18n.DefaultLanguage {
+	case "en", "ru":
+	default:
+		errs = append(errs, fmt.Errorf("i18n-default-language must be en or ru, got %q", c.I18n.DefaultLanguage))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			errs = append(errs, errors.New("tracing-endpoint is required for the otlp exporter"))
+		}
+	case "file":
+		if c.Tracing.File == "" {
+			errs = append(errs, errors.New("tracing-file is required for the file exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing-exporter must be one of none, otlp, stdout, file, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing-sample-ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+	if c.Auth.Enabled {
+		if c.Auth.HS256Secret == "" && c.Auth.JWKSFile == "" && c.Auth.JWKSURL == "" {
+			errs = append(errs, errors.New("auth requires auth-hs256-secret, auth-jwks-file or auth-jwks-url; set auth-enabled=false only for local development"))
+		}
+		if c.Auth.JWKSFile != "" && c.Auth.JWKSURL != "" {
+			errs = append(errs, errors.New("set only one of auth-jwks-file and auth-jwks-url"))
+		}
+		if c.Auth.JWKSRefresh <= 0 {
+			errs = append(errs, errors.New("auth-jwks-refresh must be positive"))
+		}
+		if c.Auth.RolesClaim == "" {
+			errs = append(errs, errors.New("auth-roles-claim is required"))
+		}
+		if c.Auth.OrgClaim == "" {
+			errs = append(errs, errors.New("auth-org-claim is required"))
+		}
+	}
+	if c.RateLimit.Enabled {
+		rules := []struct {
+			name string
+			rule RateLimitRule
+		}{
+			{"default", c.RateLimit.Default},
+			{"write", c.RateLimit.Write},
+			{"expensive", c.RateLimit.Expensive},
+			{"ip", c.RateLimit.IP},
+		}
+		for _, r := range rules {
+			if r.rule.PerMinute <= 0 || r.rule.Burst <= 0 {
+				errs = append(errs, fmt.Errorf("rate-limit-%s-per-minute and rate-limit-%s-burst must be positive", r.name, r.name))
+			}
+		}
+	}
+	if c.CORS.AllowCredentials && slices.Contains(c.CORS.AllowedOrigins, "*") {
+		errs = append(errs, errors.New("cors-allow-credentials cannot be combined with cors-allowed-origins *"))
+	}
+	if c.Compression.MinSize < 0 {
+		errs = append(errs, errors.New("compression-min-size must not be negative"))
+	}
+	if c.Features.Metrics && (c.Features.MetricsAddr == "" || c.Features.MetricsAddr == c.HTTP.Addr) {
+		errs = append(errs, errors.New("metrics-addr is required with feature-metrics and must differ from http-addr"))
+	}
+	if c.Features.Calendar && c.Features.CalendarSecret == "" {
+		errs = append(errs, errors.New("calendar-secret is required when feature-calendar is enabled"))
+	}
+	if c.Webhooks.Enabled {
+		if c.Webhooks.Timeout <= 0 || c.Webhooks.PollInterval <= 0 || c.Webhooks.ScanInterval <= 0 {
+			errs = append(errs, errors.New("webhooks-timeout, webhooks-poll-interval and webhooks-scan-interval must be positive"))
+		}
+		if c.Webhooks.MaxAttempts < 1 {
+			errs = append(errs, errors.New("webhooks-max-attempts must be at least 1"))
+		}
+		if c.Webhooks.BackoffBase <= 0 || c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+			errs = append(errs, errors.New("webhooks-backoff-base must be positive and not greater than webhooks-backoff-max"))
+		}
+		if c.Webhooks.RenewalNotice <= 0 {
+			errs = append(errs, errors.New("webhooks-renewal-notice must be positive"))
+		}
+	}
+	if c.Seed.Users < 0 || c.Seed.Random < 0 {
+		errs = append(errs, errors.New("seed-users and seed-random must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// LogValue представляет действующую конфигурацию для slog, скрывая секреты
+func (c *Config) LogValue() slog.Value {
+	settings := c.settings()
+	attrs := make([]slog.Attr, 0, len(settings))
+	for _, s := range settings {
+		v := s.get()
+		if s.secret && v != "" {
+			v = "******"
+		}
+		attrs = append(attrs, slog.String(s.key, v))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// flagValue запоминает значение флага, чтобы применить его после файла и окружения.
+// Логические флаги, как и в пакете flag, можно указывать без значения: -auth-enabled
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+func (f *flagValue) Set(v string) error {
+	f.value = v
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+func (s setting) set(v string) error {
+	switch p := s.ptr.(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("expected integer, got %q", v)
+		}
+		*p = n
+	case *float64:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("expected number, got %q", v)
+		}
+		*p = f
+	case *bool:
+		switch strings.ToLower(v) {
+		case "1", "true", "yes", "on":
+			*p = true
+		case "0", "false", "no", "off":
+			*p = false
+		default:
+			return fmt.Errorf("expected boolean, got %q", v)
+		}
+	case *time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*p = d
+	case *[]string:
+		// Списки задаются через запятую, пустые элементы отбрасываются
+		*p = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+	}
+	return nil
+}
+
+func (s setting) get() string {
+	switch p := s.ptr.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *float64:
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	case *bool:
+		return fmt.Sprint(*p)
+	case *time.Duration:
+		return p.String()
+	case *[]string:
+		return strings.Join(*p, ",")
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requiredEnv задаёт обязательные настройки БД и переводит тест во временный каталог без .env
+func requiredEnv(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_USER", "user")
+	t.Setenv("DB_PASSWORD", "password")
+	t.Setenv("DB_NAME", "subscriptions")
+	t.Setenv("AUTH_HS256_SECRET", "secret")
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	dir := requiredEnv(t)
+	path := writeFile(t, dir, "config.yaml", `
+http:
+  addr: ":7000"
+  read_timeout: 3s
+log:
+  level: debug
+  format: json
+db:
+  port: 6000
+`)
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("DB_PORT", "6500")
+
+	cfg, err := LoadConfig([]string{"-config", path, "-db-port", "7000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"default", cfg.HTTP.WriteTimeout, 60 * time.Second},
+		{"file over default", cfg.HTTP.Addr, ":7000"},
+		{"file duration", cfg.HTTP.ReadTimeout, 3 * time.Second},
+		{"file kept without env", cfg.Log.Format, "json"},
+		{"env over file", cfg.Log.Level, "warn"},
+		{"flag over env and file", cfg.DB.Port, 7000},
+		{"env only", cfg.DB.Host, "db"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		check func(*Config) bool
+	}{
+		{
+			name:  "bare bool flag does not swallow the next flag",
+			args:  []string{"-rate-limit-trust-forwarded-for", "-http-addr", ":9000"},
+			check: func(c *Config) bool { return c.RateLimit.TrustForwardedFor && c.HTTP.Addr == ":9000" },
+		},
+		{
+			name:  "bare bool flags in a row",
+			args:  []string{"-webhooks-enabled", "-webhooks-allow-private", "-tracing-insecure"},
+			check: func(c *Config) bool { return c.Webhooks.Enabled && c.Webhooks.AllowPrivate && c.Tracing.Insecure },
+		},
+		{
+			name:  "explicit false",
+			args:  []string{"-auth-enabled=false", "-feature-export=false"},
+			check: func(c *Config) bool { return !c.Auth.Enabled && !c.Features.Export },
+		},
+		{
+			name:  "bool flag over env",
+			args:  []string{"-compression-enabled"},
+			check: func(c *Config) bool { return c.Compression.Enabled },
+		},
+		{
+			name: "list flag",
+			args: []string{"-cors-allowed-origins", "https://a.example, ,https://b.example"},
+			check: func(c *Config) bool {
+				return strings.Join(c.CORS.AllowedOrigins, "|") == "https://a.example|https://b.example"
+			},
+		},
+		{
+			name:  "duration flag",
+			args:  []string{"-http-shutdown-timeout", "45s"},
+			check: func(c *Config) bool { return c.HTTP.ShutdownTimeout == 45*time.Second },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requiredEnv(t)
+			t.Setenv("COMPRESSION_ENABLED", "false")
+			cfg, err := LoadConfig(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("LoadConfig(%q) produced an unexpected config", tt.args)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	dir := requiredEnv(t)
+	path := writeFile(t, dir, "config.toml", `
+[http]
+addr = ":7100"
+
+[rate_limit.write]
+per_minute = 10
+burst = 2
+`)
+	cfg, err := LoadConfig([]string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Addr != ":7100" || cfg.RateLimit.Write != (RateLimitRule{PerMinute: 10, Burst: 2}) {
+		t.Errorf("http.addr = %q, rate_limit.write = %+v", cfg.HTTP.Addr, cfg.RateLimit.Write)
+	}
+	if cfg.RateLimit.Default != Default().RateLimit.Default {
+		t.Errorf("rate_limit.default = %+v, want the default", cfg.RateLimit.Default)
+	}
+}
+
+func TestLoadConfigDotEnv(t *testing.T) {
+	dir := requiredEnv(t)
+	writeFile(t, dir, ".env", "HTTP_ADDR=:7200\nLOG_LEVEL=error\n")
+	// godotenv пишет прочитанные значения в окружение процесса
+	t.Cleanup(func() { os.Unsetenv("HTTP_ADDR") })
+	// Уже заданная переменная окружения важнее .env
+	t.Setenv("LOG_LEVEL", "debug")
+	cfg, err := LoadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Addr != ":7200" || cfg.Log.Level != "debug" {
+		t.Errorf("http-addr = %q, log-level = %q; want :7200 from .env and debug from the environment", cfg.HTTP.Addr, cfg.Log.Level)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		file    string
+		args    []string
+		wantErr string
+	}{
+		{name: "invalid env", env: map[string]string{"DB_PORT": "five"}, wantErr: "invalid env variable DB_PORT"},
+		{name: "invalid bool env", env: map[string]string{"AUTH_ENABLED": "maybe"}, wantErr: "expected boolean"},
+		{name: "invalid flag", args: []string{"-http-read-timeout", "soon"}, wantErr: "invalid flag -http-read-timeout"},
+		{name: "invalid bool flag", args: []string{"-auth-enabled=maybe"}, wantErr: "invalid flag -auth-enabled"},
+		{name: "unknown flag", args: []string{"-port", "9000"}, wantErr: "flag provided but not defined"},
+		{name: "missing required", env: map[string]string{"DB_HOST": ""}, wantErr: "missing required setting: db-host"},
+		{name: "auth without keys", env: map[string]string{"AUTH_HS256_SECRET": ""}, wantErr: "auth requires"},
+		{name: "validation", args: []string{"-log-level", "verbose"}, wantErr: "log-level must be one of"},
+		{name: "unsupported file", file: "config.json", wantErr: "unsupported extension"},
+		{name: "missing file", file: "missing.yaml", wantErr: "config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := requiredEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(dir, tt.file)
+				if tt.file != "missing.yaml" {
+					writeFile(t, dir, tt.file, "{}")
+				}
+				args = append([]string{"-config", path}, args...)
+			}
+			_, err := LoadConfig(args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadConfig() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogValueRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.DB.Password = "s3cr3t-db"
+	cfg.Auth.HS256Secret = "s3cr3t-jwt"
+	cfg.Features.CalendarSecret = "s3cr3t-calendar"
+	cfg.DB.Host = "db.internal"
+
+	out := cfg.LogValue().String()
+	for _, secret := range []string{"s3cr3t-db", "s3cr3t-jwt", "s3cr3t-calendar"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("effective config contains secret %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "db.internal") {
+		t.Errorf("effective config does not contain db-host: %s", out)
+	}
+}
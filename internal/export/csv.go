@@ -0,0 +1,34 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+// WriteRow записывает строку и сразу сбрасывает буфер, чтобы клиент получал данные по мере чтения из БД
+func (c *csvWriter) WriteRow(sub model.Subscription) error {
+	if err := c.w.Write(row(sub)); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,139 @@
+// Package export реализует потоковую выгрузку подписок в табличные форматы.
+package export
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+)
+
+// Поддерживаемые медиа-типы выгрузки
+const (
+	MediaTypeJSON   = "application/json"
+	MediaTypeCSV    = "text/csv"
+	MediaTypeXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MediaTypeNDJSON = "application/x-ndjson"
+)
+
+// ErrUnsupportedFormat возвращается для неизвестного медиа-типа
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Columns заголовки колонок табличных форматов
+var Columns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_period", "trial_end_date"}
+
+// Writer построчно записывает подписки в выходной поток
+type Writer interface {
+	WriteRow(sub model.Subscription) error
+	Close() error
+}
+
+// NewWriter создаёт Writer для указанного медиа-типа
+func NewWriter(mediaType string, w io.Writer) (Writer, error) {
+	switch mediaType {
+	case MediaTypeCSV:
+		return newCSVWriter(w)
+	case MediaTypeXLSX:
+		return newXLSXWriter(w)
+	case MediaTypeNDJSON:
+		return newNDJSONWriter(w), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// FileExtension возвращает расширение файла для медиа-типа
+func FileExtension(mediaType string) string {
+	switch mediaType {
+	case MediaTypeCSV:
+		return "csv"
+	case MediaTypeXLSX:
+		return "xlsx"
+	case MediaTypeNDJSON:
+		return "ndjson"
+	}
+	return "json"
+}
+
+// Negotiate выбирает формат ответа по заголовку Accept.
+// При равном q конкретный тип важнее диапазона вида text/* и */*, а среди равных — первый в заголовке.
+// Без заголовка или при отсутствии совпадений возвращается JSON.
+func Negotiate(accept string) string {
+	type candidate struct {
+		mediaType string
+		q         float64
+		order     int
+	}
+	var candidates []candidate
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{mediaType, q, i})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.q != b.q {
+			return a.q > b.q
+		}
+		if sa, sb := specificity(a.mediaType), specificity(b.mediaType); sa != sb {
+			return sa > sb
+		}
+		return a.order < b.order
+	})
+	for _, c := range candidates {
+		switch c.mediaType {
+		case MediaTypeCSV, MediaTypeXLSX, MediaTypeNDJSON, MediaTypeJSON:
+			return c.mediaType
+		case "*/*", "application/*":
+			return MediaTypeJSON
+		}
+	}
+	return MediaTypeJSON
+}
+
+// specificity ранжирует медиа-диапазон: */* < type/* < type/subtype
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// row представляет подписку в виде строк для табличных форматов
+func row(sub model.Subscription) []string {
+	endDate := ""
+	if sub.EndDate != nil {
+		endDate = sub.EndDate.Format("01-2006")
+	}
+	trialEndDate := ""
+	if sub.TrialEndDate != nil {
+		trialEndDate = sub.TrialEndDate.Format("01-2006")
+	}
+	return []string{
+		sub.ID.String(),
+		sub.ServiceName,
+		strconv.Itoa(sub.Price),
+		sub.UserID.String(),
+		sub.StartDate.Format("01-2006"),
+		endDate,
+		string(sub.BillingPeriod),
+		trialEndDate,
+	}
+}
@@ -0,0 +1,213 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", MediaTypeJSON},
+		{"text/csv", MediaTypeCSV},
+		{MediaTypeXLSX, MediaTypeXLSX},
+		{"application/x-ndjson", MediaTypeNDJSON},
+		{"text/html", MediaTypeJSON},
+		{"*/*", MediaTypeJSON},
+		{"text/csv;q=0.5, application/x-ndjson", MediaTypeNDJSON},
+		{"application/x-ndjson;q=0.2, text/csv;q=0.9", MediaTypeCSV},
+		{"text/csv;q=0, application/x-ndjson;q=0.1", MediaTypeNDJSON},
+		{"*/*, text/csv", MediaTypeCSV},
+		{"application/*, text/csv", MediaTypeCSV},
+		{"text/csv, application/x-ndjson", MediaTypeCSV},
+		{"application/x-ndjson, text/csv", MediaTypeNDJSON},
+		{"text/html, text/csv;q=0.8", MediaTypeCSV},
+		{"text/csv;charset=utf-8", MediaTypeCSV},
+		{"not a media type, text/csv", MediaTypeCSV},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.accept); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		i    int
+		want string
+	}{
+		{0, "A"},
+		{7, "H"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.i); got != tt.want {
+			t.Errorf("columnName(%d) = %s, want %s", tt.i, got, tt.want)
+		}
+	}
+}
+
+func testSubscription() model.Subscription {
+	end := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	return model.Subscription{
+		ID:            uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		ServiceName:   `Yandex "Plus" <Family>`,
+		Price:         400,
+		UserID:        uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:     time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:       &end,
+		BillingPeriod: model.BillingMonthly,
+	}
+}
+
+// TestWritersStreamRows проверяет, что каждая строка попадает в выходной поток сразу после WriteRow,
+// а не после Close: иначе выгрузка накапливалась бы в памяти до конца выборки
+func TestWritersStreamRows(t *testing.T) {
+	for _, mediaType := range []string{MediaTypeCSV, MediaTypeNDJSON, MediaTypeXLSX} {
+		t.Run(FileExtension(mediaType), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(mediaType, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 3; i++ {
+				before := buf.Len()
+				if err := w.WriteRow(testSubscription()); err != nil {
+					t.Fatal(err)
+				}
+				if buf.Len() == before {
+					t.Fatalf("row %d was buffered instead of written", i)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(MediaTypeCSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := testSubscription()
+	if err := w.WriteRow(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{Columns, {
+		sub.ID.String(), sub.ServiceName, "400", sub.UserID.String(), "07-2025", "12-2025", "monthly", "",
+	}}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("record %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(MediaTypeNDJSON, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := testSubscription()
+	for i := 0; i < 2; i++ {
+		if err := w.WriteRow(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var got model.Subscription
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		if got.ID != sub.ID || got.ServiceName != sub.ServiceName || got.Price != sub.Price {
+			t.Errorf("decoded %+v, want %+v", got, sub)
+		}
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(MediaTypeXLSX, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(testSubscription()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("workbook is not a valid zip archive: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(b)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook has no part %s", name)
+		}
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t>id</t></is></c>`,
+		`<c r="C2"><v>400</v></c>`,
+		`<t>Yandex &#34;Plus&#34; &lt;Family&gt;</t>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet does not contain %s:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestNewWriterUnsupported(t *testing.T) {
+	if _, err := NewWriter("text/html", io.Discard); err != ErrUnsupportedFormat {
+		t.Errorf("NewWriter(text/html) error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
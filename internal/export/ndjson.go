@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+)
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+// WriteRow записывает подписку отдельной JSON-строкой в том же виде, что и JSON API
+func (n *ndjsonWriter) WriteRow(sub model.Subscription) error {
+	return n.enc.Encode(sub)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
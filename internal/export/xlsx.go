@@ -0,0 +1,118 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+)
+
+// Минимальный набор служебных частей книги Office Open XML с одним листом
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Subscriptions" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// xlsxWriter пишет книгу XLSX напрямую в zip-поток, не накапливая строки в памяти
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	// deflate сжимает последнюю созданную часть архива, то есть лист
+	deflate *flate.Writer
+	rowN    int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	x := &xlsxWriter{zw: zip.NewWriter(w)}
+	// Свой компрессор нужен, чтобы сбрасывать его после каждой строки: zip.Writer.Flush
+	// сбрасывает только свой буфер, а сжатые данные остаются внутри flate до закрытия части
+	x.zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		fw, err := flate.NewWriter(out, flate.DefaultCompression)
+		x.deflate = fw
+		return fw, err
+	})
+	for _, part := range xlsxParts {
+		f, err := x.zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+	// Лист создаётся последним: после него в архив ничего не пишется, и строки можно дописывать потоково
+	f, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x.sheet = bufio.NewWriter(f)
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err := x.writeCells(Columns, -1); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// WriteRow записывает подписку строкой листа; цена сохраняется числом
+func (x *xlsxWriter) WriteRow(sub model.Subscription) error {
+	if err := x.writeCells(row(sub), 2); err != nil {
+		return err
+	}
+	if err := x.deflate.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Flush()
+}
+
+// writeCells записывает строку листа; колонка numericCol пишется числом, остальные — строками
+func (x *xlsxWriter) writeCells(values []string, numericCol int) error {
+	x.rowN++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rowN)
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(x.rowN)
+		if i == numericCol {
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, v)
+			continue
+		}
+		fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t>`, ref)
+		if err := xml.EscapeText(x.sheet, []byte(v)); err != nil {
+			return err
+		}
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	x.sheet.WriteString(`</row>`)
+	return x.sheet.Flush()
+}
+
+func (x *xlsxWriter) Close() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// columnName переводит индекс колонки в буквенное обозначение Excel (0 -> A, 26 -> AA)
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
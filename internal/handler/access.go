@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// restrictedUserID возвращает пользователя, которым ограничен доступ вызывающей стороны.
+// ok == false означает доступ ко всем пользователям: политика выдала на действие маршрута доступ all
+// или аутентификация отключена.
+func restrictedUserID(r *http.Request) (userID uuid.UUID, ok bool) {
+	p, authenticated := auth.FromContext(r.Context())
+	if !authenticated || auth.AccessFromContext(r.Context()) == auth.AccessAll {
+		return uuid.Nil, false
+	}
+	return p.UserID, true
+}
+
+// visibleSubscriptions возвращает запрос к подпискам организации запроса, доступным вызывающей стороне
+func (h *Handler) visibleSubscriptions(r *http.Request) *gorm.DB {
+	return h.db(r).Model(&model.Subscription{}).Scopes(visibleTo(r))
+}
+
+// visibleTo ограничивает запрос подписками, доступными вызывающей стороне; нужен внутри транзакций
+func visibleTo(r *http.Request) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		query = query.Where("org_id = ?", currentOrg(r).ID)
+		if userID, ok := restrictedUserID(r); ok {
+			query = query.Where("user_id = ?", userID)
+		}
+		return query
+	}
+}
+
+// authorizeUser проверяет, что вызывающая сторона может работать с данными пользователя userID,
+// и отвечает 403, если нет
+func authorizeUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	if own, ok := restrictedUserID(r); ok && own != userID {
+		respondError(w, r, http.StatusForbidden, "foreign_user_denied")
+		return false
+	}
+	return true
+}
+
+// requireSubscription проверяет, что подписка существует и доступна вызывающей стороне.
+// Чужая подписка неотличима от несуществующей: в обоих случаях ответ 404.
+func (h *Handler) requireSubscription(w http.ResponseWriter, r *http.Request, subID uuid.UUID) bool {
+	var count int64
+	if err := h.visibleSubscriptions(r).Where("id = ?", subID).Count(&count).Error; err != nil {
+		respondErr(w, r, err)
+		return false
+	}
+	if count == 0 {
+		respondErr(w, r, errSubscriptionNotFound)
+		return false
+	}
+	return true
+}
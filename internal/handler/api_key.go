@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateAPIKeyInput входные данные для выпуска API-ключа
+type CreateAPIKeyInput struct {
+	Name string `json:"name" example:"billing-job"`
+	// Scopes read, write, reports, admin
+	Scopes []string `json:"scopes" example:"read,reports"`
+	// ExpiresAt момент окончания действия в RFC 3339; без него ключ бессрочный
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-12-31T23:59:59Z"`
+}
+
+// validate проверяет все поля запроса; нарушения возвращаются сразу в виде validation.Errors
+func (in CreateAPIKeyInput) validate(now time.Time) error {
+	var v validation.Validator
+	if v.Required("/name", in.Name) {
+		v.MaxLength("/name", in.Name, maxNameLength)
+	}
+	if v.Check(len(in.Scopes) > 0, "/scopes", validation.CodeRequired, "validation.required") {
+		for i, scope := range in.Scopes {
+			v.Check(slices.Contains(auth.Actions, scope), validation.Pointer("scopes", strconv.Itoa(i)),
+				validation.CodeNotAllowed, "validation.one_of", strings.Join(auth.Actions, ", "))
+		}
+	}
+	if in.ExpiresAt != nil {
+		v.Check(in.ExpiresAt.After(now), "/expires_at", validation.CodeInPast, "validation.in_past")
+	}
+	return v.Err()
+}
+
+// APIKeyWithSecret API-ключ вместе с его значением, которое возвращается только при выпуске и ротации
+type APIKeyWithSecret struct {
+	model.APIKey
+	Key string `json:"key" example:"osk_1a2b3c4d5e6f_9sQ..."`
+}
+
+// @Summary Выпустить API-ключ
+// @Description Создаёт ключ для межсервисного доступа к данным текущей организации. Значение ключа возвращается один раз, хранится только его хэш.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param input body handler.CreateAPIKeyInput true "Параметры ключа"
+// @Success 201 {object} handler.APIKeyWithSecret
+// @Failure 400 {object} handler.Problem "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var input CreateAPIKeyInput
+	if !h.decodeJSON(w, r, &input) {
+		return
+	}
+	if err := input.validate(time.Now()); err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	key, raw, err := h.APIKeys.Issue(r.Context(), currentOrg(r).ID, input.Name, input.Scopes, input.ExpiresAt)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "api key issued", "key_id", key.ID, "name", key.Name, "scopes", key.Scopes)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(APIKeyWithSecret{APIKey: *key, Key: raw})
+}
+
+// @Summary Список API-ключей
+// @Description Возвращает все ключи организации, включая отозванные и истёкшие, без их значений
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} model.APIKey
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys [get]
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.APIKeys.List(r.Context(), currentOrg(r).ID)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// @Summary Ротировать API-ключ
+// @Description Выдаёт новое значение ключа с теми же областями и сроком действия, старое значение сразу перестаёт работать
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "UUID ключа"
+// @Success 200 {object} handler.APIKeyWithSecret
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys/{id}/rotate [post]
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_api_key_id")
+		return
+	}
+	key, raw, err := h.APIKeys.Rotate(r.Context(), currentOrg(r).ID, id)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "api key rotated", "key_id", key.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(APIKeyWithSecret{APIKey: *key, Key: raw})
+}
+
+// @Summary Отозвать API-ключ
+// @Description Отзывает ключ; запись остаётся в списке с отметкой revoked_at
+// @Tags api-keys
+// @Param id path string true "UUID ключа"
+// @Success 204
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_api_key_id")
+		return
+	}
+	err = h.APIKeys.Revoke(r.Context(), currentOrg(r).ID, id)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "api key revoked", "key_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
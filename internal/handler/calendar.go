@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/billing"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/ical"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CalendarLinkResponse ссылка на календарь продлений пользователя
+type CalendarLinkResponse struct {
+	OrgID uuid.UUID `json:"org_id"`
+	Token string    `json:"token" example:"3f2a9c..."`
+	URL   string    `json:"url" example:"/users/a1b2c3d4-e5f6-7g8h-9i0j-k1l2m3n4o5p6/renewals.ics?token=3f2a9c..."`
+}
+
+// calendarToken вычисляет секретный токен ленты календаря пользователя в организации.
+// Лента открывается без аутентификации, поэтому организацию несёт сам токен: её ID
+// и HMAC от организации и пользователя, так что подменить организацию в токене нельзя.
+func (h *Handler) calendarToken(orgID, userID uuid.UUID) string {
+	mac := hmac.New(sha256.New, h.CalendarSecret)
+	mac.Write(orgID[:])
+	mac.Write(userID[:])
+	return hex.EncodeToString(mac.Sum(orgID[:]))
+}
+
+// calendarTokenOrg возвращает организацию из токена календаря, если подпись токена верна для userID
+func (h *Handler) calendarTokenOrg(token string, userID uuid.UUID) (uuid.UUID, bool) {
+	raw, err := hex.DecodeString(token)
+	if err != nil || len(raw) != len(uuid.Nil)+sha256.Size {
+		return uuid.Nil, false
+	}
+	orgID, err := uuid.FromBytes(raw[:len(uuid.Nil)])
+	if err != nil || !hmac.Equal([]byte(token), []byte(h.calendarToken(orgID, userID))) {
+		return uuid.Nil, false
+	}
+	return orgID, true
+}
+
+// @Summary Календарь продлений
+// @Description Возвращает календарь iCalendar (RFC 5545) с событиями продления по расписанию списаний для каждой активной подписки пользователя в организации;
+// @Description после запланированного изменения цены продления идут отдельным событием с новой суммой
+// @Tags calendar
+// @Produce text/calendar
+// @Param user_id path string true "UUID пользователя"
+// @Param token query string true "Секретный токен календаря, определяет и организацию"
+// @Success 200 {string} string "Календарь iCalendar"
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 500 {object} handler.ErrorResponse "Internal Server Error"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Router /users/{user_id}/renewals.ics [get]
+func (h *Handler) GetRenewalsCalendar(w http.ResponseWriter, r *http.Request) {
+	if len(h.CalendarSecret) == 0 {
+		respondError(w, r, http.StatusNotFound, "calendar_disabled")
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+	orgID, ok := h.calendarTokenOrg(r.URL.Query().Get("token"), userID)
+	if !ok {
+		respondError(w, r, http.StatusForbidden, "invalid_calendar_token")
+		return
+	}
+
+	now := time.Now()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var subs []model.Subscription
+	if err := h.db(r).Preload("Pauses").Preload("PriceChanges").Where("org_id = ? AND user_id = ? AND (end_date IS NULL OR end_date >= ?)", orgID, userID, currentMonth).
+		Order("start_date").Find(&subs).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	cal := ical.Calendar{
+		ProdID: "-//onlineSubscriptions//Renewals//EN",
+		Name:   "Subscription renewals",
+	}
+	for _, sub := range subs {
+		cal.Events = append(cal.Events, renewalEvents(sub)...)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="renewals.ics"`)
+	if err := cal.Encode(w); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write calendar", "error", err)
+	}
+}
+
+// renewalEvents строит повторяющиеся события по расписанию списаний подписки: по одному на каждую
+// цену, чтобы в описании была сумма, действующая на эти даты. Ограниченные паузы исключаются
+// через EXDATE, бессрочная пауза обрывает повторение.
+func renewalEvents(sub model.Subscription) []ical.Event {
+	first := billing.FirstChargeDate(sub)
+	until := sub.EndDate
+	var exDates []time.Time
+	for _, p := range sub.Pauses {
+		if p.EndDate == nil {
+			last := p.StartDate.AddDate(0, 0, -1)
+			if until == nil || last.Before(*until) {
+				until = &last
+			}
+			continue
+		}
+		billing.ForEachDate(sub, *p.EndDate, func(date time.Time) {
+			if !date.Before(p.StartDate) {
+				exDates = append(exDates, date)
+			}
+		})
+	}
+
+	// Новое событие начинается с каждого изменения цены, вступающего в силу после первого списания
+	var changes []time.Time
+	for _, c := range sub.PriceChanges {
+		if c.EffectiveDate.After(first) && (until == nil || !c.EffectiveDate.After(*until)) {
+			changes = append(changes, c.EffectiveDate)
+		}
+	}
+	slices.SortFunc(changes, time.Time.Compare)
+	changes = slices.CompactFunc(changes, time.Time.Equal)
+
+	var events []ical.Event
+	for i := 0; i <= len(changes); i++ {
+		start, uid := first, sub.ID.String()
+		if i > 0 {
+			start = billing.NextChargeDate(sub, changes[i-1])
+			uid += "-" + start.Format("20060102")
+		}
+		end := until
+		if i < len(changes) {
+			last := changes[i].AddDate(0, 0, -1)
+			end = &last
+		}
+		if end != nil && start.After(*end) {
+			continue
+		}
+
+		rrule := "FREQ=MONTHLY"
+		if months := sub.BillingPeriod.Months(); months > 1 {
+			rrule += fmt.Sprintf(";INTERVAL=%d", months)
+		}
+		if end != nil {
+			rrule += ";UNTIL=" + ical.FormatDate(*end)
+		}
+		var excluded []time.Time
+		for _, d := range exDates {
+			if !d.Before(start) && (end == nil || !d.After(*end)) {
+				excluded = append(excluded, d)
+			}
+		}
+		events = append(events, ical.Event{
+			UID:         uid + "@onlineSubscriptions",
+			Summary:     "Renewal: " + sub.ServiceName,
+			Description: fmt.Sprintf("Price: %d (%s)", billing.PriceAt(sub, start), sub.BillingPeriod),
+			Start:       start,
+			RRule:       rrule,
+			ExDates:     excluded,
+		})
+	}
+	return events
+}
+
+// @Summary Ссылка на календарь продлений
+// @Description Возвращает секретный токен и ссылку для подписки на календарь продлений пользователя в текущей организации
+// @Tags calendar
+// @Produce json
+// @Param user_id path string true "UUID пользователя"
+// @Success 200 {object} handler.CalendarLinkResponse
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{user_id}/renewals/link [get]
+func (h *Handler) GetRenewalsCalendarLink(w http.ResponseWriter, r *http.Request) {
+	if len(h.CalendarSecret) == 0 {
+		respondError(w, r, http.StatusNotFound, "calendar_disabled")
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+	if !authorizeUser(w, r, userID) {
+		return
+	}
+	orgID := currentOrg(r).ID
+	token := h.calendarToken(orgID, userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CalendarLinkResponse{
+		OrgID: orgID,
+		Token: token,
+		URL:   fmt.Sprintf("/users/%s/renewals.ics?token=%s", userID, token),
+	})
+}
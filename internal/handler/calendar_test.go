@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+)
+
+func month(y int, m time.Month) time.Time {
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestRenewalEvents(t *testing.T) {
+	id := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	base := model.Subscription{ID: id, ServiceName: "Netflix", Price: 400, BillingPeriod: model.BillingMonthly, StartDate: month(2025, 1)}
+	with := func(change func(*model.Subscription)) model.Subscription {
+		sub := base
+		change(&sub)
+		return sub
+	}
+	type event struct {
+		uid, start, rrule, description string
+		exDates                        []string
+	}
+	tests := []struct {
+		name string
+		sub  model.Subscription
+		want []event
+	}{
+		{
+			name: "open-ended monthly",
+			sub:  base,
+			want: []event{{id.String(), "20250101", "FREQ=MONTHLY", "Price: 400 (monthly)", nil}},
+		},
+		{
+			name: "quarterly with end date and trial",
+			sub: with(func(s *model.Subscription) {
+				s.BillingPeriod = model.BillingQuarterly
+				s.TrialEndDate = ptr(month(2025, 2))
+				s.EndDate = ptr(month(2025, 12))
+			}),
+			want: []event{{id.String(), "20250401", "FREQ=MONTHLY;INTERVAL=3;UNTIL=20251201", "Price: 400 (quarterly)", nil}},
+		},
+		{
+			name: "bounded pause is excluded",
+			sub: with(func(s *model.Subscription) {
+				s.Pauses = []model.SubscriptionPause{{StartDate: month(2025, 3), EndDate: ptr(month(2025, 4))}}
+			}),
+			want: []event{{id.String(), "20250101", "FREQ=MONTHLY", "Price: 400 (monthly)", []string{"20250301", "20250401"}}},
+		},
+		{
+			name: "open-ended pause stops the series",
+			sub: with(func(s *model.Subscription) {
+				s.Pauses = []model.SubscriptionPause{{StartDate: month(2025, 6)}}
+			}),
+			want: []event{{id.String(), "20250101", "FREQ=MONTHLY;UNTIL=20250531", "Price: 400 (monthly)", nil}},
+		},
+		{
+			name: "price change splits the series",
+			sub: with(func(s *model.Subscription) {
+				s.PriceChanges = []model.PriceChange{{EffectiveDate: month(2025, 5), Price: 500}}
+				s.Pauses = []model.SubscriptionPause{{StartDate: month(2025, 3), EndDate: ptr(month(2025, 6))}}
+			}),
+			want: []event{
+				{id.String(), "20250101", "FREQ=MONTHLY;UNTIL=20250430", "Price: 400 (monthly)", []string{"20250301", "20250401"}},
+				{id.String() + "-20250501", "20250501", "FREQ=MONTHLY", "Price: 500 (monthly)", []string{"20250501", "20250601"}},
+			},
+		},
+		{
+			name: "price change after the end date is ignored",
+			sub: with(func(s *model.Subscription) {
+				s.EndDate = ptr(month(2025, 6))
+				s.PriceChanges = []model.PriceChange{{EffectiveDate: month(2025, 9), Price: 500}}
+			}),
+			want: []event{{id.String(), "20250101", "FREQ=MONTHLY;UNTIL=20250601", "Price: 400 (monthly)", nil}},
+		},
+		{
+			name: "price change before the first charge sets its price",
+			sub: with(func(s *model.Subscription) {
+				s.TrialEndDate = ptr(month(2025, 3))
+				s.PriceChanges = []model.PriceChange{{EffectiveDate: month(2025, 2), Price: 300}}
+			}),
+			want: []event{{id.String(), "20250301", "FREQ=MONTHLY", "Price: 300 (monthly)", nil}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renewalEvents(tt.sub)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, e := range got {
+				w := tt.want[i]
+				var exDates []string
+				for _, d := range e.ExDates {
+					exDates = append(exDates, d.Format("20060102"))
+				}
+				if e.UID != w.uid+"@onlineSubscriptions" || e.Start.Format("20060102") != w.start ||
+					e.RRule != w.rrule || e.Description != w.description ||
+					strings.Join(exDates, ",") != strings.Join(w.exDates, ",") {
+					t.Errorf("event %d = {%s %s %s %q %v}, want %+v",
+						i, e.UID, e.Start.Format("20060102"), e.RRule, e.Description, exDates, w)
+				}
+			}
+		})
+	}
+}
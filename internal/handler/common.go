@@ -1,30 +1,64 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/export"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/health"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/webhook"
+
 	"gorm.io/gorm"
 )
 
-// ErrorResponse формат ошибок API
-type ErrorResponse struct {
-	Error string `json:"error" example:"описание ошибки"`
-}
+// ErrorResponse формат ошибок API; общий с middleware аутентификации и ограничения частоты запросов
+type ErrorResponse = i18n.ErrorResponse
 
 // Handler базовый обработчик
 type Handler struct {
 	DB *gorm.DB
+	// ExportEnabled разрешает выгрузку списков в форматах, отличных от JSON
+	ExportEnabled bool
+	// CalendarSecret ключ токенов календаря; пустой, если календарь отключён
+	CalendarSecret []byte
+	// Readiness проверки для /readyz
+	Readiness *health.Registry
+	// APIKeys хранилище ключей для межсервисного доступа
+	APIKeys *auth.APIKeys
+	// Policy политика доступа ролей
+	Policy *auth.Policy
+	// MaxBodySize наибольший размер тела запроса в байтах
+	MaxBodySize int64
+	// Webhooks nil, если вебхуки отключены
+	Webhooks *webhook.Webhooks
 }
 
 // NewHandler создает новый экземпляр обработчика
-func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{DB: db}
+func NewHandler(db *gorm.DB, cfg *config.Config, readiness *health.Registry) *Handler {
+	h := &Handler{DB: db, ExportEnabled: cfg.Features.Export, Readiness: readiness, MaxBodySize: int64(cfg.HTTP.MaxBodySize)}
+	if cfg.Features.Calendar {
+		h.CalendarSecret = []byte(cfg.Features.CalendarSecret)
+	}
+	return h
+}
+
+// negotiateExport возвращает медиа-тип выгрузки по заголовку Accept, либо JSON, если выгрузка отключена
+func (h *Handler) negotiateExport(r *http.Request) string {
+	if !h.ExportEnabled {
+		return export.MediaTypeJSON
+	}
+	return export.Negotiate(r.Header.Get("Accept"))
+}
+
+// db возвращает соединение, привязанное к контексту запроса, чтобы журнал запросов получал request_id
+func (h *Handler) db(r *http.Request) *gorm.DB {
+	return h.DB.WithContext(r.Context())
 }
 
-// respondError отправляет ошибку в формате JSON
-func respondError(w http.ResponseWriter, code int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+// respondError отправляет ошибку с кодом code и сообщением из каталога i18n на языке запроса.
+// Идентификатор запроса берётся из заголовка ответа, выставленного requestIDMiddleware.
+func respondError(w http.ResponseWriter, r *http.Request, status int, code string, args ...any) {
+	i18n.WriteError(w, r, status, code, args...)
 }
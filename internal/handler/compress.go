@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/andybalholm/brotli"
+)
+
+// brotliLevel уровень сжатия br: заметно лучше gzip при сопоставимой скорости
+const brotliLevel = 4
+
+// compressibleTypes типы содержимого, которые имеет смысл сжимать; XLSX уже является zip-архивом
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/problem+json",
+	"application/x-ndjson",
+	"application/xml",
+	"application/javascript",
+}
+
+var (
+	gzipPool   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, brotliLevel) }}
+)
+
+// compressMiddleware сжимает ответы gzip или br по заголовку Accept-Encoding.
+// Ответ буферизуется до cfg.MinSize байт: короткие ответы отдаются как есть, длинные (списки, выгрузки) сжимаются потоком.
+func compressMiddleware(cfg config.CompressionConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: cfg.MinSize, status: http.StatusOK}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding выбирает br или gzip с ненулевым q из Accept-Encoding, br предпочтительнее
+func negotiateEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		accepted[strings.ToLower(name)] = q > 0
+	}
+	for _, enc := range []string{"br", "gzip"} {
+		if accepted[enc] {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressWriter откладывает отправку заголовков, пока не станет ясно, нужно ли сжатие
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if !cw.decided {
+		cw.status = code
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+	if !cw.compressible() {
+		cw.start(false)
+		return cw.ResponseWriter.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.minSize {
+		cw.start(true)
+	}
+	return len(p), nil
+}
+
+// Flush отправляет клиенту всё записанное: ответ, который ещё буферизуется, сразу начинает сжиматься,
+// если тип подходит, — сбрасывающий буфер обработчик отдаёт поток, а не короткий ответ
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.start(cw.compressible())
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap открывает исходный writer для http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// compressible проверяет, что обработчик не сжал ответ сам и тип содержимого подходит
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// start отправляет заголовки и накопленный буфер, со сжатием или без
+func (cw *compressWriter) start(compress bool) {
+	cw.decided = true
+	if compress {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		switch cw.encoding {
+		case "br":
+			bw := brotliPool.Get().(*brotli.Writer)
+			bw.Reset(cw.ResponseWriter)
+			cw.enc = bw
+		default:
+			gw := gzipPool.Get().(*gzip.Writer)
+			gw.Reset(cw.ResponseWriter)
+			cw.enc = gw
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) > 0 {
+		if cw.enc != nil {
+			cw.enc.Write(cw.buf)
+		} else {
+			cw.ResponseWriter.Write(cw.buf)
+		}
+	}
+	cw.buf = nil
+}
+
+// Close дописывает ответ: короткий отдаётся без сжатия, у сжатого завершается поток
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		cw.start(false)
+		return nil
+	}
+	if cw.enc == nil {
+		return nil
+	}
+	err := cw.enc.Close()
+	switch e := cw.enc.(type) {
+	case *brotli.Writer:
+		brotliPool.Put(e)
+	case *gzip.Writer:
+		gzipPool.Put(e)
+	}
+	return err
+}
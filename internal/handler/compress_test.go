@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, br", "br"},
+		{"GZIP;q=0.5", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"deflate, gzip;q=0.1", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func decompress(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case "br":
+		r = brotli.NewReader(body)
+	default:
+		r = body
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	long := strings.Repeat(`{"service_name":"Netflix"}`, 100)
+	tests := []struct {
+		name           string
+		method         string
+		accept         string
+		contentType    string
+		contentEncoded bool
+		body           string
+		status         int
+		wantEncoding   string
+	}{
+		{name: "gzip", accept: "gzip", contentType: "application/json", body: long, status: http.StatusOK, wantEncoding: "gzip"},
+		{name: "br preferred", accept: "gzip, br", contentType: "application/json; charset=utf-8", body: long, status: http.StatusOK, wantEncoding: "br"},
+		{name: "status is kept", accept: "gzip", contentType: "application/problem+json", body: long, status: http.StatusBadRequest, wantEncoding: "gzip"},
+		{name: "short response", accept: "gzip", contentType: "application/json", body: `{"id":1}`, status: http.StatusOK},
+		{name: "incompressible type", accept: "gzip", contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", body: long, status: http.StatusOK},
+		{name: "already encoded", accept: "gzip", contentType: "text/plain", contentEncoded: true, body: long, status: http.StatusOK, wantEncoding: "identity"},
+		{name: "no accept-encoding", contentType: "application/json", body: long, status: http.StatusOK},
+		{name: "head", method: http.MethodHead, accept: "gzip", contentType: "application/json", status: http.StatusOK},
+		{name: "empty body", accept: "gzip", contentType: "application/json", status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := compressMiddleware(config.CompressionConfig{Enabled: true, MinSize: 1024})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", tt.contentType)
+					if tt.contentEncoded {
+						w.Header().Set("Content-Encoding", "identity")
+					}
+					w.WriteHeader(tt.status)
+					// Запись частями проверяет буферизацию до MinSize
+					for chunk := range slices.Chunk([]byte(tt.body), 100) {
+						w.Write(chunk)
+					}
+				}))
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/subscriptions", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if got := decompress(t, tt.wantEncoding, rec.Body); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+// TestCompressFlush проверяет, что Flush до набора MinSize начинает сжатый поток и отправляет клиенту уже записанное
+func TestCompressFlush(t *testing.T) {
+	for _, encoding := range []string{"gzip", "br"} {
+		t.Run(encoding, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler := compressMiddleware(config.CompressionConfig{Enabled: true, MinSize: 1024})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/x-ndjson")
+					io.WriteString(w, "{\"row\":1}\n")
+					if err := http.NewResponseController(w).Flush(); err != nil {
+						t.Fatalf("Flush() error = %v", err)
+					}
+					if !rec.Flushed || rec.Body.Len() == 0 {
+						t.Error("flushed row did not reach the client")
+					}
+					io.WriteString(w, "{\"row\":2}\n")
+				}))
+			r := httptest.NewRequest(http.MethodGet, "/subscriptions/export", nil)
+			r.Header.Set("Accept-Encoding", encoding)
+			handler.ServeHTTP(rec, r)
+
+			if got := rec.Header().Get("Content-Encoding"); got != encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, encoding)
+			}
+			if got := decompress(t, encoding, rec.Body); got != "{\"row\":1}\n{\"row\":2}\n" {
+				t.Errorf("body = %q", got)
+			}
+		})
+	}
+}
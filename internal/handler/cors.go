@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+)
+
+// corsExposedHeaders заголовки ответа, которые браузерный клиент может прочитать
+var corsExposedHeaders = strings.Join([]string{
+	requestIDHeader,
+	"Retry-After",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"RateLimit-Policy",
+	"Content-Disposition",
+	nextCursorHeader,
+}, ", ")
+
+// corsMiddleware разрешает запросы из браузера с источников из cfg.AllowedOrigins
+// и сам отвечает на preflight-запросы
+func corsMiddleware(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	allowAny := slices.Contains(cfg.AllowedOrigins, "*")
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if !allowAny && !slices.Contains(cfg.AllowedOrigins, origin) {
+				// Без заголовков Access-Control-* браузер сам не отдаст ответ странице
+				next.ServeHTTP(w, r)
+				return
+			}
+			if allowAny && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", methods)
+				h.Set("Access-Control-Allow-Headers", headers)
+				h.Set("Access-Control-Max-Age", maxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// preflightFallback отвечает на OPTIONS, которые не обработал corsMiddleware (например, с чужого источника).
+// Без этого маршрута mux ответил бы 405 раньше, чем сработают middleware.
+func preflightFallback(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/health"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	base := config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	}
+	withCredentials := base
+	withCredentials.AllowCredentials = true
+	anyOrigin := base
+	anyOrigin.AllowedOrigins = []string{"*"}
+	anyWithCredentials := anyOrigin
+	anyWithCredentials.AllowCredentials = true
+
+	tests := []struct {
+		name      string
+		cfg       config.CORSConfig
+		method    string
+		origin    string
+		preflight bool
+		status    int
+		header    map[string]string
+	}{
+		{
+			name: "no origin", cfg: base, method: http.MethodGet, status: http.StatusOK,
+			header: map[string]string{"Access-Control-Allow-Origin": "", "Vary": ""},
+		},
+		{
+			name: "allowed origin", cfg: base, method: http.MethodGet, origin: "https://app.example", status: http.StatusOK,
+			header: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example",
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Allow-Methods":     "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name: "foreign origin", cfg: base, method: http.MethodGet, origin: "https://evil.example", status: http.StatusOK,
+			header: map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Expose-Headers": "", "Vary": "Origin"},
+		},
+		{
+			name: "preflight", cfg: base, method: http.MethodOptions, origin: "https://app.example", preflight: true, status: http.StatusNoContent,
+			header: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example",
+				"Access-Control-Allow-Methods":  "GET, POST",
+				"Access-Control-Allow-Headers":  "Authorization, Content-Type",
+				"Access-Control-Max-Age":        "600",
+				"Access-Control-Expose-Headers": "",
+			},
+		},
+		{
+			name: "options without request method is passed through", cfg: base, method: http.MethodOptions, origin: "https://app.example", status: http.StatusOK,
+			header: map[string]string{"Access-Control-Allow-Methods": ""},
+		},
+		{
+			name: "credentials echo the origin", cfg: withCredentials, method: http.MethodGet, origin: "https://app.example", status: http.StatusOK,
+			header: map[string]string{"Access-Control-Allow-Origin": "https://app.example", "Access-Control-Allow-Credentials": "true"},
+		},
+		{
+			name: "any origin", cfg: anyOrigin, method: http.MethodGet, origin: "https://other.example", status: http.StatusOK,
+			header: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Credentials": ""},
+		},
+		{
+			name: "any origin with credentials echoes the origin", cfg: anyWithCredentials, method: http.MethodGet, origin: "https://other.example", status: http.StatusOK,
+			header: map[string]string{"Access-Control-Allow-Origin": "https://other.example", "Access-Control-Allow-Credentials": "true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := corsMiddleware(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			r := httptest.NewRequest(tt.method, "/subscriptions", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			for name, want := range tt.header {
+				if got := strings.Join(rec.Header().Values(name), ", "); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSExposesRateLimitHeaders(t *testing.T) {
+	for _, name := range []string{"Retry-After", "RateLimit-Remaining", requestIDHeader, nextCursorHeader} {
+		if !strings.Contains(corsExposedHeaders, name) {
+			t.Errorf("Access-Control-Expose-Headers does not contain %s", name)
+		}
+	}
+}
+
+// TestPreflightToAnyPath проверяет, что preflight к маршруту без OPTIONS не получает 405 от mux
+func TestPreflightToAnyPath(t *testing.T) {
+	db, _ := dryRunDB(t)
+	cfg := config.Default()
+	cfg.CORS.AllowedOrigins = []string{"https://app.example"}
+	router := SetupRouter(Deps{DB: db, Config: cfg, Readiness: health.NewRegistry()})
+
+	r := httptest.NewRequest(http.MethodOptions, "/subscriptions/11111111-1111-1111-1111-111111111111", nil)
+	r.Header.Set("Origin", "https://app.example")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, r)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Errorf("preflight: status = %d, Access-Control-Allow-Methods = %q", rec.Code, rec.Header().Get("Access-Control-Allow-Methods"))
+	}
+}
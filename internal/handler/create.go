@@ -2,13 +2,11 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
-	"time"
 
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
-	"github.com/google/uuid"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+	"gorm.io/gorm"
 )
 
 // CreateSubscriptionInput входные данные для создания подписки
@@ -18,60 +16,92 @@ type CreateSubscriptionInput struct {
 	UserID      string  `json:"user_id" example:"a1b2c3d4-e5f6-7g8h-9i0j-k1l2m3n4o5p6"`
 	StartDate   string  `json:"start_date" example:"01-2023"`
 	EndDate     *string `json:"end_date,omitempty" example:"12-2023"`
+	// BillingPeriod monthly (по умолчанию), quarterly, semiannual или yearly
+	BillingPeriod string  `json:"billing_period,omitempty" example:"monthly"`
+	TrialEndDate  *string `json:"trial_end_date,omitempty" example:"02-2023"`
+}
+
+// maxNameLength ограничение длины названий в символах
+const maxNameLength = 255
+
+// billingPeriods перечисляет допустимые периоды списаний для сообщения о нарушении
+const billingPeriods = "monthly, quarterly, semiannual, yearly"
+
+// toSubscription проверяет все поля и собирает из них подписку.
+// Нарушения возвращаются все сразу в виде validation.Errors.
+func (in CreateSubscriptionInput) toSubscription() (model.Subscription, error) {
+	var v validation.Validator
+	if v.Required("/service_name", in.ServiceName) {
+		v.MaxLength("/service_name", in.ServiceName, maxNameLength)
+	}
+	v.Min("/price", in.Price, 0)
+	sub := model.Subscription{
+		ServiceName:   in.ServiceName,
+		Price:         in.Price,
+		UserID:        v.UUID("/user_id", in.UserID),
+		StartDate:     v.Month("/start_date", in.StartDate),
+		EndDate:       v.OptionalMonth("/end_date", in.EndDate),
+		BillingPeriod: model.BillingMonthly,
+	}
+	if in.BillingPeriod != "" {
+		sub.BillingPeriod = model.BillingPeriod(in.BillingPeriod)
+		v.Check(sub.BillingPeriod.Months() != 0, "/billing_period", validation.CodeNotAllowed, "validation.one_of", billingPeriods)
+	}
+	sub.TrialEndDate = v.OptionalMonth("/trial_end_date", in.TrialEndDate)
+	checkSubscriptionDates(&v, &sub)
+	return sub, v.Err()
+}
+
+// checkSubscriptionDates проверяет, что окончание подписки и пробного периода не раньше её начала
+func checkSubscriptionDates(v *validation.Validator, sub *model.Subscription) {
+	if sub.EndDate != nil {
+		v.NotBefore("/end_date", *sub.EndDate, sub.StartDate, "start_date")
+	}
+	if sub.TrialEndDate != nil {
+		v.NotBefore("/trial_end_date", *sub.TrialEndDate, sub.StartDate, "start_date")
+	}
 }
 
 // @Summary Создать подписку
-// @Description Создаёт новую онлайн-подписку
+// @Description Создаёт новую онлайн-подписку. Все нарушения во входных данных возвращаются сразу
+// @Description в формате application/problem+json (RFC 7807) с кодом и JSON Pointer для каждого поля.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param input body handler.CreateSubscriptionInput true "Данные подписки"
 // @Success 201 {object} model.Subscription
-// @Failure 400 {object} handler.ErrorResponse
+// @Failure 400 {object} handler.Problem "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions [post]
 func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 	var input CreateSubscriptionInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		log.Printf("Failed to decode request body: %v", err)
-		respondError(w, http.StatusBadRequest, "invalid JSON")
-		return
-	}
-	if input.ServiceName == "" {
-		respondError(w, http.StatusBadRequest, "Service name is required")
-		return
-	}
-	if input.Price < 0 {
-		respondError(w, http.StatusBadRequest, "Price must be > 0")
+	if !h.decodeJSON(w, r, &input) {
 		return
 	}
-	userUUID, err := uuid.Parse(input.UserID)
+	sub, err := input.toSubscription()
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "user_id must be valid UUID")
+		respondInvalid(w, r, err)
 		return
 	}
-	startDate, err := time.Parse("01-2006", input.StartDate)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid start date format")
+	if !authorizeUser(w, r, sub.UserID) {
 		return
 	}
-	var endDatePtr *time.Time
-	if input.EndDate != nil {
-		t, err := time.Parse("01-2006", *input.EndDate)
-		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid end date format")
-			return
+	sub.OrgID = currentOrg(r).ID
+	err = h.db(r).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sub).Error; err != nil {
+			return err
 		}
-		endDatePtr = &t
-	}
-	sub := model.Subscription{
-		ServiceName: input.ServiceName,
-		Price:       input.Price,
-		UserID:      userUUID,
-		StartDate:   startDate,
-		EndDate:     endDatePtr,
-	}
-	if err := h.DB.Create(&sub).Error; err != nil {
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to create subscription: %v", err))
+		return h.publish(tx, model.EventSubscriptionCreated, sub)
+	})
+	if err != nil {
+		respondErr(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
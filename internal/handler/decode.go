@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+)
+
+// decodeJSON строго разбирает тело запроса в dst и при ошибке сам отвечает клиенту.
+// Тело должно иметь Content-Type application/json (415), не превышать MaxBodySize (413),
+// содержать ровно одно JSON-значение без лишних данных после него и без неизвестных полей.
+// Для синтаксических ошибок в ответе указываются строка и столбец.
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		respondError(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type")
+		return false
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.MaxBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(w, r, http.StatusRequestEntityTooLarge, "body_too_large", tooLarge.Limit)
+		return false
+	}
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to read request body", "error", err)
+		respondError(w, r, http.StatusBadRequest, "invalid_json")
+		return false
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		respondError(w, r, http.StatusBadRequest, "empty_body")
+		return false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		slog.WarnContext(r.Context(), "failed to decode request body", "error", err)
+		respondDecodeError(w, r, data, err)
+		return false
+	}
+	rest := data[dec.InputOffset():]
+	if trimmed := bytes.TrimLeft(rest, " \t\r\n"); len(trimmed) > 0 {
+		line, col := position(data, len(data)-len(trimmed))
+		respondError(w, r, http.StatusBadRequest, "trailing_data", line, col)
+		return false
+	}
+	return true
+}
+
+// respondDecodeError отвечает на ошибку encoding/json: неизвестные поля и неверные типы
+// возвращаются как нарушения с JSON Pointer, синтаксические ошибки — с позицией в теле
+func respondDecodeError(w http.ResponseWriter, r *http.Request, data []byte, err error) {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		v         validation.Validator
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := position(data, int(syntaxErr.Offset)-1)
+		respondError(w, r, http.StatusBadRequest, "invalid_json_at", line, col)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		line, col := position(data, len(data))
+		respondError(w, r, http.StatusBadRequest, "invalid_json_at", line, col)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		v.Add(validation.Pointer(strings.Split(typeErr.Field, ".")...), validation.CodeInvalidType,
+			"validation.invalid_type", typeErr.Type.String())
+		respondInvalid(w, r, v.Err())
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json не экспортирует тип этой ошибки, имя поля есть только в тексте
+		field, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		if unquoteErr != nil {
+			field = strings.TrimPrefix(err.Error(), "json: unknown field ")
+		}
+		v.Add(validation.Pointer(field), validation.CodeUnknownField, "validation.unknown_field")
+		respondInvalid(w, r, v.Err())
+	default:
+		respondError(w, r, http.StatusBadRequest, "invalid_json")
+	}
+}
+
+// position переводит смещение в байтах в номер строки и столбца, начиная с 1
+func position(data []byte, offset int) (line, col int) {
+	offset = max(0, min(offset, len(data)))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+	return line, col
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type nested struct {
+		Months int `json:"months"`
+	}
+	type input struct {
+		Name   string `json:"name"`
+		Price  int    `json:"price"`
+		Period nested `json:"period"`
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+		message     string
+		pointer     string
+	}{
+		{name: "valid", body: `{"name":"Netflix","price":400}`, status: http.StatusOK},
+		{name: "charset parameter", contentType: "application/json; charset=utf-8", body: `{"name":"Netflix"}`, status: http.StatusOK},
+		{name: "trailing whitespace", body: "{\"price\":1}\n\t ", status: http.StatusOK},
+		{name: "missing content type", contentType: "-", body: `{}`, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+		{name: "wrong content type", contentType: "text/plain", body: `{}`, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+		{name: "too large", body: `{"name":"` + strings.Repeat("x", 64) + `"}`, status: http.StatusRequestEntityTooLarge,
+			code: "body_too_large", message: "Request body exceeds 64 bytes"},
+		{name: "empty", body: " \n", status: http.StatusBadRequest, code: "empty_body"},
+		{name: "syntax error position", body: "{\n  \"name\": \"a\",\n  \"price\": 1,,\n}", status: http.StatusBadRequest,
+			code: "invalid_json_at", message: "Malformed JSON at line 3, column 14"},
+		{name: "syntax error after multibyte text", body: `{"name":"подписка" x}`, status: http.StatusBadRequest,
+			code: "invalid_json_at", message: "Malformed JSON at line 1, column 20"},
+		{name: "truncated", body: "{\"name\":\n\"a\"", status: http.StatusBadRequest,
+			code: "invalid_json_at", message: "Malformed JSON at line 2, column 4"},
+		{name: "trailing data", body: "{\"price\":1}\n{\"price\":2}", status: http.StatusBadRequest,
+			code: "trailing_data", message: i18n.T(i18n.English, "trailing_data", 2, 1)},
+		{name: "unknown field", body: `{"name":"a","prise":1}`, status: http.StatusBadRequest, code: "validation_failed", pointer: "/prise"},
+		{name: "wrong type", body: `{"price":"400"}`, status: http.StatusBadRequest, code: "validation_failed", pointer: "/price"},
+		{name: "wrong nested type", body: `{"period":{"months":"3"}}`, status: http.StatusBadRequest, code: "validation_failed", pointer: "/period/months"},
+		{name: "not an object", body: `[1]`, status: http.StatusBadRequest, code: "invalid_json"},
+	}
+	h := &Handler{MaxBodySize: 64}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(tt.body))
+			switch tt.contentType {
+			case "":
+				r.Header.Set("Content-Type", "application/json")
+			case "-":
+			default:
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			var dst input
+			ok := h.decodeJSON(rec, r, &dst)
+			if ok != (tt.status == http.StatusOK) {
+				t.Fatalf("decodeJSON() = %v, response %d %s", ok, rec.Code, rec.Body)
+			}
+			if ok {
+				return
+			}
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			var resp struct {
+				Code   string `json:"code"`
+				Error  string `json:"error"`
+				Errors []struct {
+					Pointer string `json:"pointer"`
+				} `json:"errors"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tt.code {
+				t.Errorf("code = %q, want %q", resp.Code, tt.code)
+			}
+			if tt.message != "" && resp.Error != tt.message {
+				t.Errorf("error = %q, want %q", resp.Error, tt.message)
+			}
+			if tt.pointer != "" && (len(resp.Errors) != 1 || resp.Errors[0].Pointer != tt.pointer) {
+				t.Errorf("errors = %+v, want one violation at %s", resp.Errors, tt.pointer)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	data := []byte("ab\nвгд\n")
+	tests := []struct {
+		offset    int
+		line, col int
+	}{
+		{-5, 1, 1},
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{7, 2, 3},
+		{100, 3, 1},
+	}
+	for _, tt := range tests {
+		if line, col := position(data, tt.offset); line != tt.line || col != tt.col {
+			t.Errorf("position(%d) = %d:%d, want %d:%d", tt.offset, line, col, tt.line, tt.col)
+		}
+	}
+}
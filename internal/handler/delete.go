@@ -6,6 +6,8 @@ import (
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // @Summary Удалить подписку
@@ -15,21 +17,34 @@ import (
 // @Success 204
 // @Failure 400 {object} handler.ErrorResponse "Bad Request"
 // @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [delete]
 func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	subIDStr := mux.Vars(r)["id"]
 	subID, err := uuid.Parse(subIDStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid subscription ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
 		return
 	}
-	res := h.DB.Where("id = ?", subID).Delete(&model.Subscription{})
-	if res.Error != nil {
-		respondError(w, http.StatusBadRequest, "Failed to delete subscription")
-		return
-	}
-	if res.RowsAffected == 0 {
-		respondError(w, http.StatusNotFound, "Subscription not found")
+	// RETURNING возвращает удалённую подписку для тела события subscription.deleted
+	err = h.db(r).Transaction(func(tx *gorm.DB) error {
+		var sub model.Subscription
+		res := tx.Scopes(visibleTo(r)).Clauses(clause.Returning{}).Where("id = ?", subID).Delete(&sub)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errSubscriptionNotFound
+		}
+		return h.publish(tx, model.EventSubscriptionDeleted, sub)
+	})
+	if err != nil {
+		respondErr(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
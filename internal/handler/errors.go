@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/apperr"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/repository"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+	"gorm.io/gorm"
+)
+
+// unavailableRetryAfter через сколько секунд клиенту стоит повторить запрос, если хранилище недоступно
+const unavailableRetryAfter = 5
+
+// Ненайденные записи; чужая запись неотличима от несуществующей
+var (
+	errSubscriptionNotFound = apperr.New(apperr.KindNotFound, "subscription_not_found")
+	errPauseNotFound        = apperr.New(apperr.KindNotFound, "pause_not_found")
+	errPriceChangeNotFound  = apperr.New(apperr.KindNotFound, "price_change_not_found")
+	errOrganizationNotFound = apperr.New(apperr.KindNotFound, "organization_not_found")
+)
+
+// kindStatus HTTP-статус для каждого вида доменной ошибки
+var kindStatus = map[apperr.Kind]int{
+	apperr.KindInternal:    http.StatusInternalServerError,
+	apperr.KindNotFound:    http.StatusNotFound,
+	apperr.KindConflict:    http.StatusConflict,
+	apperr.KindValidation:  http.StatusBadRequest,
+	apperr.KindUnavailable: http.StatusServiceUnavailable,
+}
+
+// orNotFound заменяет gorm.ErrRecordNotFound ошибкой конкретного ресурса
+func orNotFound(err error, notFound *apperr.Error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
+
+// respondErr единственное место, где ошибка превращается в HTTP-ответ. Ошибки GORM и pgx
+// переводятся в доменные (repository.Translate), статус определяется видом ошибки:
+// клиент отличает некорректный запрос (4xx) от временной недоступности (503, можно повторить).
+func respondErr(w http.ResponseWriter, r *http.Request, err error) {
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		respondInvalid(w, r, violations)
+		return
+	}
+	var e *apperr.Error
+	if !errors.As(repository.Translate(err), &e) {
+		e = apperr.Wrap(apperr.KindInternal, "internal_error", err)
+	}
+	switch e.Kind {
+	case apperr.KindInternal:
+		slog.ErrorContext(r.Context(), "request failed", "error", err)
+	case apperr.KindUnavailable:
+		slog.WarnContext(r.Context(), "storage unavailable", "error", err)
+		w.Header().Set("Retry-After", strconv.Itoa(unavailableRetryAfter))
+	case apperr.KindConflict, apperr.KindValidation:
+		if e.Err != nil {
+			slog.InfoContext(r.Context(), "request rejected by storage", "kind", e.Kind.String(), "error", err)
+		}
+	}
+	respondError(w, r, kindStatus[e.Kind], e.Code, e.Args...)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/apperr"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestRespondErr(t *testing.T) {
+	var v validation.Validator
+	v.Min("/price", -1, 0)
+	tests := []struct {
+		name        string
+		err         error
+		status      int
+		code        string
+		contentType string
+		retryAfter  string
+	}{
+		{"domain not found", fmt.Errorf("get: %w", errSubscriptionNotFound), http.StatusNotFound, "subscription_not_found", "application/json", ""},
+		{"record not found", orNotFound(gorm.ErrRecordNotFound, errPauseNotFound), http.StatusNotFound, "pause_not_found", "application/json", ""},
+		{"unique violation", &pgconn.PgError{Code: "23505", ConstraintName: "organizations_slug_key"}, http.StatusConflict, "already_exists", "application/json", ""},
+		{"foreign key violation", &pgconn.PgError{Code: "23503", ConstraintName: "pauses_subscription_id_fkey"}, http.StatusConflict, "reference_conflict", "application/json", ""},
+		{"data exception", &pgconn.PgError{Code: "22003"}, http.StatusBadRequest, "invalid_data", "application/json", ""},
+		{"storage unavailable", &pgconn.PgError{Code: "57P01"}, http.StatusServiceUnavailable, "service_unavailable", "application/json", "5"},
+		{"internal", errors.New("boom"), http.StatusInternalServerError, "internal_error", "application/json", ""},
+		{"validation", v.Err(), http.StatusBadRequest, "validation_failed", problemContentType, ""},
+		{"domain validation with args", apperr.New(apperr.KindValidation, "window_too_long", 24), http.StatusBadRequest, "window_too_long", "application/json", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			respondErr(rec, httptest.NewRequest(http.MethodPost, "/organizations", nil), tt.err)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.retryAfter)
+			}
+			body := rec.Body.String()
+			var resp struct {
+				Code  string `json:"code"`
+				Error string `json:"error"`
+				Title string `json:"title"`
+			}
+			if err := json.Unmarshal([]byte(body), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tt.code {
+				t.Errorf("code = %q, want %q", resp.Code, tt.code)
+			}
+			if msg := resp.Error + resp.Title; msg == "" || msg == tt.code || strings.Contains(msg, "%!") {
+				t.Errorf("message = %q, want a catalog message for %s", msg, tt.code)
+			}
+			// Имена ограничений и текст исходной ошибки остаются в журнале
+			for _, leaked := range []string{"organizations_slug_key", "pauses_subscription_id_fkey", "boom", "SQLSTATE"} {
+				if strings.Contains(body, leaked) {
+					t.Errorf("response leaks %q: %s", leaked, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/export"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"gorm.io/gorm"
+)
+
+// exportFlushRows через сколько строк выгрузка отправляется клиенту, не дожидаясь заполнения буферов
+const exportFlushRows = 100
+
+// streamSubscriptions построчно выгружает результат запроса в выбранном формате,
+// не загружая всю выборку в память
+func (h *Handler) streamSubscriptions(w http.ResponseWriter, r *http.Request, query *gorm.DB, mediaType string) {
+	ctx := r.Context()
+	rows, err := query.Model(&model.Subscription{}).Rows()
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="subscriptions.%s"`, export.FileExtension(mediaType)))
+	ew, err := export.NewWriter(mediaType, w)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to start export", "error", err)
+		return
+	}
+	rc := http.NewResponseController(w)
+	for n := 1; rows.Next(); n++ {
+		var sub model.Subscription
+		if err := query.ScanRows(rows, &sub); err != nil {
+			slog.ErrorContext(ctx, "failed to scan subscription row", "error", err)
+			return
+		}
+		if err := ew.WriteRow(sub); err != nil {
+			slog.ErrorContext(ctx, "failed to write export row", "error", err)
+			return
+		}
+		if n%exportFlushRows == 0 {
+			rc.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "failed to iterate subscriptions", "error", err)
+		return
+	}
+	if err := ew.Close(); err != nil {
+		slog.ErrorContext(ctx, "failed to finish export", "error", err)
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/metrics"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/tracing"
+)
+
+// TestFlushReachesClient проверяет, что сброс выгрузки проходит через обёртки ResponseWriter
+// журнала, метрик и трассировки: без Unwrap http.ResponseController не находит Flush
+// и выгрузка доходит до клиента только целиком
+func TestFlushReachesClient(t *testing.T) {
+	db, _ := dryRunDB(t)
+	m, err := metrics.New(db, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flushErr error
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("id,service_name\n"))
+		flushErr = http.NewResponseController(w).Flush()
+	})
+	for _, mw := range []func(http.Handler) http.Handler{loggingMiddleware, m.Middleware, tracing.Middleware, requestIDMiddleware} {
+		handler = mw(handler)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+	if flushErr != nil {
+		t.Fatalf("Flush() = %v", flushErr)
+	}
+	if !rec.Flushed {
+		t.Error("response was not flushed to the client")
+	}
+}
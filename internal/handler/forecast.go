@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/billing"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultForecastMonths = 12
+	maxForecastMonths     = 60
+)
+
+// ForecastMonth прогноз расходов за один месяц
+type ForecastMonth struct {
+	Month     string         `json:"month" example:"01-2025"`
+	Total     int            `json:"total" example:"1198"`
+	ByUser    map[string]int `json:"by_user"`
+	ByService map[string]int `json:"by_service"`
+}
+
+// ForecastResponse помесячный прогноз расходов с итогами за весь горизонт
+type ForecastResponse struct {
+	Months    int             `json:"months" example:"12"`
+	Currency  string          `json:"currency" example:"RUB"`
+	Series    []ForecastMonth `json:"series"`
+	Total     int             `json:"total" example:"14376"`
+	ByUser    map[string]int  `json:"by_user"`
+	ByService map[string]int  `json:"by_service"`
+}
+
+// @Summary Прогноз расходов
+// @Description Прогнозирует помесячные расходы по пользователям и сервисам на основе активных подписок, запланированных изменений цены и дат окончания
+// @Tags subscriptions
+// @Produce json
+// @Param months query int false "Горизонт прогноза в месяцах, начиная с текущего (1-60, по умолчанию 12)"
+// @Param user_id query string false "UUID пользователя"
+// @Param service_name query string false "Название сервиса"
+// @Success 200 {object} handler.ForecastResponse
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 500 {object} handler.ErrorResponse "Internal Server Error"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/forecast [get]
+func (h *Handler) GetSubscriptionForecast(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	months := defaultForecastMonths
+	if s := q.Get("months"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxForecastMonths {
+			respondError(w, r, http.StatusBadRequest, "invalid_months", maxForecastMonths)
+			return
+		}
+		months = n
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, months, 0).AddDate(0, 0, -1)
+
+	query := h.visibleSubscriptions(r).Preload("Pauses").Preload("PriceChanges").
+		Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", to, from)
+	if userID := q.Get("user_id"); userID != "" {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+			return
+		}
+		if !authorizeUser(w, r, userUUID) {
+			return
+		}
+		query = query.Where("user_id = ?", userUUID)
+	}
+	if serviceName := q.Get("service_name"); serviceName != "" {
+		query = query.Where("service_name = ?", serviceName)
+	}
+	var subs []model.Subscription
+	if err := query.Find(&subs).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	resp := ForecastResponse{
+		Months:    months,
+		Currency:  currentOrg(r).DefaultCurrency,
+		Series:    make([]ForecastMonth, months),
+		ByUser:    map[string]int{},
+		ByService: map[string]int{},
+	}
+	for i := range resp.Series {
+		resp.Series[i] = ForecastMonth{
+			Month:     from.AddDate(0, i, 0).Format("01-2006"),
+			ByUser:    map[string]int{},
+			ByService: map[string]int{},
+		}
+	}
+	for _, sub := range subs {
+		for _, c := range billing.Charges(sub, from, to) {
+			i := (c.Date.Year()-from.Year())*12 + int(c.Date.Month()-from.Month())
+			m := &resp.Series[i]
+			m.Total += c.Amount
+			m.ByUser[c.UserID.String()] += c.Amount
+			m.ByService[c.ServiceName] += c.Amount
+			resp.Total += c.Amount
+			resp.ByUser[c.UserID.String()] += c.Amount
+			resp.ByService[c.ServiceName] += c.Amount
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
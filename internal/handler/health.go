@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/health"
+)
+
+// HealthResponse ответ проверки живости
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс работает
+// @Tags health
+// @Produce json
+// @Success 200 {object} handler.HealthResponse
+// @Router /healthz [get]
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HealthResponse{Status: health.StatusOK})
+}
+
+// @Summary Проверка готовности
+// @Description Проверяет доступность БД, применённые миграции и фоновые задачи. Во время плавной остановки возвращает 503
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.Readiness.Run(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -1,11 +1,24 @@
 package handler
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/logging"
+	"github.com/google/uuid"
 )
 
+// requestIDHeader заголовок с идентификатором запроса
+const requestIDHeader = i18n.RequestIDHeader
+
+// maxRequestIDLength ограничивает длину принятого от клиента идентификатора
+const maxRequestIDLength = 128
+
 type loggingResponseWriter struct {
 	http.ResponseWriter
 	status int
@@ -16,16 +29,80 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap открывает исходный writer для http.ResponseController, например для Flush
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+// requestIDMiddleware берёт X-Request-ID клиента или генерирует новый,
+// возвращает его в ответе и сохраняет в контексте запроса для журнала
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID допускает только непустые короткие идентификаторы из печатных ASCII-символов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
 		lrw := &loggingResponseWriter{w, http.StatusOK}
 
-		log.Printf("[REQ]  %s %s", r.Method, r.URL.Path)
+		slog.DebugContext(r.Context(), "request started", "method", r.Method, "path", r.URL.Path)
 		next.ServeHTTP(lrw, r)
 		duration := time.Since(start)
 
-		log.Printf("[RESP] %s %s completed in %v with %d", r.Method, r.URL.Path, duration, lrw.status)
+		level := slog.LevelInfo
+		if lrw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(r.Context(), level, "request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", lrw.status,
+			"duration", duration,
+			"remote_addr", r.RemoteAddr)
 	})
 }
+
+// securityHeadersMiddleware добавляет стандартные заголовки безопасности.
+// Swagger UI исполняет скрипты, поэтому строгая Content-Security-Policy к нему не применяется.
+func securityHeadersMiddleware(cfg config.SecurityConfig) func(http.Handler) http.Handler {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds())) + "; includeSubDomains"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "no-referrer")
+			if !strings.HasPrefix(r.URL.Path, "/swagger/") {
+				h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+			}
+			if hsts != "" {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+)
+
+// OrganizationInput настройки организации; при изменении не переданные поля не меняются
+type OrganizationInput struct {
+	Name *string `json:"name,omitempty" example:"Marketing"`
+	// DefaultCurrency код валюты ISO 4217
+	DefaultCurrency *string `json:"default_currency,omitempty" example:"RUB"`
+	// FiscalYearStart номер месяца (1-12), с которого начинается финансовый год
+	FiscalYearStart *int `json:"fiscal_year_start,omitempty" example:"4"`
+}
+
+// apply переносит переданные поля в организацию и проверяет их.
+// Нарушения возвращаются все сразу в виде validation.Errors.
+func (in OrganizationInput) apply(org *model.Organization) error {
+	var v validation.Validator
+	if in.Name != nil {
+		if v.Required("/name", *in.Name) {
+			v.MaxLength("/name", *in.Name, maxNameLength)
+		}
+		org.Name = *in.Name
+	}
+	if in.DefaultCurrency != nil {
+		v.Check(validCurrency(*in.DefaultCurrency), "/default_currency", validation.CodeInvalidFormat, "validation.currency")
+		org.DefaultCurrency = *in.DefaultCurrency
+	}
+	if in.FiscalYearStart != nil {
+		v.Range("/fiscal_year_start", *in.FiscalYearStart, 1, 12)
+		org.FiscalYearStart = *in.FiscalYearStart
+	}
+	return v.Err()
+}
+
+// validCurrency допускает коды ISO 4217: три заглавные латинские буквы
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// @Summary Текущая организация
+// @Description Возвращает организацию запроса и её настройки
+// @Tags organizations
+// @Produce json
+// @Param X-Org-ID header string false "UUID организации (только для администратора платформы)"
+// @Success 200 {object} model.Organization
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /organization [get]
+func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentOrg(r))
+}
+
+// @Summary Изменить настройки организации
+// @Description Меняет название, валюту по умолчанию и начало финансового года текущей организации
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param X-Org-ID header string false "UUID организации (только для администратора платформы)"
+// @Param input body handler.OrganizationInput true "Настройки"
+// @Success 200 {object} model.Organization
+// @Failure 400 {object} handler.Problem "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /organization [put]
+func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	var input OrganizationInput
+	if !h.decodeJSON(w, r, &input) {
+		return
+	}
+	org := *currentOrg(r)
+	if err := input.apply(&org); err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	if err := h.db(r).Save(&org).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(org)
+}
+
+// @Summary Создать организацию
+// @Description Создаёт организацию. Доступно только администратору платформы, не привязанному к организации.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param input body handler.OrganizationInput true "Организация"
+// @Success 201 {object} model.Organization
+// @Failure 400 {object} handler.Problem "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Router /organizations [post]
+func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	if !h.isPlatformAdmin(r) {
+		respondError(w, r, http.StatusForbidden, "platform_admin_required")
+		return
+	}
+	var input OrganizationInput
+	if !h.decodeJSON(w, r, &input) {
+		return
+	}
+	if input.Name == nil {
+		// Пустое имя apply отклонит как обязательное поле вместе с остальными нарушениями
+		input.Name = new(string)
+	}
+	org := model.Organization{DefaultCurrency: "RUB", FiscalYearStart: 1}
+	if err := input.apply(&org); err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	if err := h.db(r).Create(&org).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "organization created", "org_id", org.ID, "name", org.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(org)
+}
+
+// @Summary Список организаций
+// @Description Возвращает все организации. Доступно только администратору платформы, не привязанному к организации.
+// @Tags organizations
+// @Produce json
+// @Success 200 {array} model.Organization
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Router /organizations [get]
+func (h *Handler) GetOrganizations(w http.ResponseWriter, r *http.Request) {
+	if !h.isPlatformAdmin(r) {
+		respondError(w, r, http.StatusForbidden, "platform_admin_required")
+		return
+	}
+	var orgs []model.Organization
+	if err := h.db(r).Order("created_at").Find(&orgs).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orgs)
+}
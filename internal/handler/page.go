@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// nextCursorHeader заголовок ответа с курсором следующей страницы списка
+const nextCursorHeader = "X-Next-Cursor"
+
+// maxPageLimit наибольший размер страницы списка
+const maxPageLimit = 1000
+
+// page параметры постраничной выдачи: limit == 0 означает список целиком
+type page struct {
+	limit int
+	after uuid.UUID
+}
+
+// parsePage разбирает параметры limit и after и отвечает 400, если они некорректны.
+// Страницы упорядочены по id, after — id последней записи предыдущей страницы.
+func parsePage(w http.ResponseWriter, r *http.Request) (page, bool) {
+	var p page
+	q := r.URL.Query()
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageLimit {
+			respondError(w, r, http.StatusBadRequest, "invalid_limit", maxPageLimit)
+			return p, false
+		}
+		p.limit = n
+	}
+	if s := q.Get("after"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil || p.limit == 0 {
+			respondError(w, r, http.StatusBadRequest, "invalid_cursor")
+			return p, false
+		}
+		p.after = id
+	}
+	return p, true
+}
+
+// apply ограничивает запрос страницей; одна лишняя запись показывает, есть ли следующая
+func (p page) apply(query *gorm.DB) *gorm.DB {
+	if p.limit == 0 {
+		return query
+	}
+	query = query.Order("id")
+	if p.after != uuid.Nil {
+		query = query.Where("id > ?", p.after)
+	}
+	return query.Limit(p.limit + 1)
+}
+
+// trimPage отбрасывает лишнюю запись и, если следующая страница есть, выставляет X-Next-Cursor
+func trimPage[T any](w http.ResponseWriter, p page, items []T, id func(T) uuid.UUID) []T {
+	if p.limit == 0 || len(items) <= p.limit {
+		return items
+	}
+	items = items[:p.limit]
+	w.Header().Set(nextCursorHeader, id(items[len(items)-1]).String())
+	return items
+}
+
+// trim trimPage для списка подписок
+func (p page) trim(w http.ResponseWriter, subs []model.Subscription) []model.Subscription {
+	return trimPage(w, p, subs, func(s model.Subscription) uuid.UUID { return s.ID })
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// CreatePauseInput входные данные для приостановки подписки
+type CreatePauseInput struct {
+	StartDate string `json:"start_date" example:"06-2024"`
+	// EndDate последний приостановленный месяц; без него пауза бессрочная
+	EndDate *string `json:"end_date,omitempty" example:"08-2024"`
+}
+
+// @Summary Приостановить подписку
+// @Description Добавляет период приостановки, в который списания не производятся
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID подписки"
+// @Param input body handler.CreatePauseInput true "Период паузы"
+// @Success 201 {object} model.SubscriptionPause
+// @Failure 400 {object} handler.Problem "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 409 {object} handler.ErrorResponse "Conflict"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/pauses [post]
+func (h *Handler) CreatePause(w http.ResponseWriter, r *http.Request) {
+	subID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
+		return
+	}
+	var input CreatePauseInput
+	if !h.decodeJSON(w, r, &input) {
+		return
+	}
+	var v validation.Validator
+	startDate := v.Month("/start_date", input.StartDate)
+	endDatePtr := v.OptionalMonth("/end_date", input.EndDate)
+	if endDatePtr != nil {
+		v.NotBefore("/end_date", *endDatePtr, startDate, "start_date")
+	}
+	if err := v.Err(); err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+
+	if !h.requireSubscription(w, r, subID) {
+		return
+	}
+
+	pause := model.SubscriptionPause{
+		SubscriptionID: subID,
+		StartDate:      startDate,
+		EndDate:        endDatePtr,
+	}
+	err = h.db(r).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&pause).Error; err != nil {
+			return err
+		}
+		return h.publishScheduleChange(tx, subID)
+	})
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(pause)
+}
+
+// @Summary Получить паузы подписки
+// @Description Возвращает периоды приостановки подписки
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "UUID подписки"
+// @Success 200 {array} model.SubscriptionPause
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/pauses [get]
+func (h *Handler) GetPauses(w http.ResponseWriter, r *http.Request) {
+	subID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
+		return
+	}
+	if !h.requireSubscription(w, r, subID) {
+		return
+	}
+	var pauses []model.SubscriptionPause
+	if err := h.db(r).Where("subscription_id = ?", subID).Order("start_date").Find(&pauses).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pauses)
+}
+
+// @Summary Удалить паузу
+// @Description Удаляет период приостановки подписки
+// @Tags subscriptions
+// @Param id path string true "UUID подписки"
+// @Param pause_id path string true "UUID паузы"
+// @Success 204
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/pauses/{pause_id} [delete]
+func (h *Handler) DeletePause(w http.ResponseWriter, r *http.Request) {
+	subID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
+		return
+	}
+	pauseID, err := uuid.Parse(mux.Vars(r)["pause_id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_pause_id")
+		return
+	}
+	if !h.requireSubscription(w, r, subID) {
+		return
+	}
+	err = h.db(r).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND subscription_id = ?", pauseID, subID).Delete(&model.SubscriptionPause{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errPauseNotFound
+		}
+		return h.publishScheduleChange(tx, subID)
+	})
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
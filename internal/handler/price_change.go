@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// CreatePriceChangeInput входные данные для планирования изменения цены
+type CreatePriceChangeInput struct {
+	EffectiveDate string `json:"effective_date" example:"01-2025"`
+	Price         int    `json:"price" example:"699"`
+}
+
+// @Summary Запланировать изменение цены
+// @Description Добавляет изменение цены подписки, действующее с указанного месяца
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID подписки"
+// @Param input body handler.CreatePriceChangeInput true "Изменение цены"
+// @Success 201 {object} model.PriceChange
+// @Failure 400 {object} handler.Problem "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 409 {object} handler.ErrorResponse "Conflict"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/price-changes [post]
+func (h *Handler) CreatePriceChange(w http.ResponseWriter, r *http.Request) {
+	subID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
+		return
+	}
+	var input CreatePriceChangeInput
+	if !h.decodeJSON(w, r, &input) {
+		return
+	}
+	var v validation.Validator
+	effectiveDate := v.Month("/effective_date", input.EffectiveDate)
+	v.Min("/price", input.Price, 0)
+	if err := v.Err(); err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+
+	if !h.requireSubscription(w, r, subID) {
+		return
+	}
+
+	change := model.PriceChange{
+		SubscriptionID: subID,
+		EffectiveDate:  effectiveDate,
+		Price:          input.Price,
+	}
+	err = h.db(r).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+		return h.publishScheduleChange(tx, subID)
+	})
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(change)
+}
+
+// @Summary Получить изменения цены
+// @Description Возвращает запланированные изменения цены подписки
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "UUID подписки"
+// @Success 200 {array} model.PriceChange
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/price-changes [get]
+func (h *Handler) GetPriceChanges(w http.ResponseWriter, r *http.Request) {
+	subID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
+		return
+	}
+	if !h.requireSubscription(w, r, subID) {
+		return
+	}
+	var changes []model.PriceChange
+	if err := h.db(r).Where("subscription_id = ?", subID).Order("effective_date").Find(&changes).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// @Summary Удалить изменение цены
+// @Description Отменяет запланированное изменение цены подписки
+// @Tags subscriptions
+// @Param id path string true "UUID подписки"
+// @Param change_id path string true "UUID изменения цены"
+// @Success 204
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/price-changes/{change_id} [delete]
+func (h *Handler) DeletePriceChange(w http.ResponseWriter, r *http.Request) {
+	subID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
+		return
+	}
+	changeID, err := uuid.Parse(mux.Vars(r)["change_id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_price_change_id")
+		return
+	}
+	if !h.requireSubscription(w, r, subID) {
+		return
+	}
+	err = h.db(r).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND subscription_id = ?", changeID, subID).Delete(&model.PriceChange{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errPriceChangeNotFound
+		}
+		return h.publishScheduleChange(tx, subID)
+	})
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+)
+
+// problemContentType медиа-тип ответа об ошибке по RFC 7807
+const problemContentType = "application/problem+json"
+
+// problemTypeValidation тип проблемы для нарушений в теле запроса
+const problemTypeValidation = "urn:problem-type:onlinesubscriptions:validation"
+
+// Problem описание ошибки по RFC 7807 с перечнем нарушений по полям
+type Problem struct {
+	Type string `json:"type" example:"urn:problem-type:onlinesubscriptions:validation"`
+	// Code машиночитаемый код, как в ErrorResponse
+	Code      string                 `json:"code" example:"validation_failed"`
+	Title     string                 `json:"title" example:"Request validation failed"`
+	Status    int                    `json:"status" example:"400"`
+	Detail    string                 `json:"detail,omitempty" example:"2 invalid field(s)"`
+	Instance  string                 `json:"instance,omitempty" example:"/subscriptions"`
+	RequestID string                 `json:"request_id,omitempty" example:"6f1c2d4e-8a9b-4c3d-9e0f-1a2b3c4d5e6f"`
+	Errors    []validation.Violation `json:"errors,omitempty"`
+}
+
+// respondProblem отправляет ошибку в формате application/problem+json
+func respondProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Instance = r.URL.Path
+	p.RequestID = w.Header().Get(requestIDHeader)
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("Content-Language", i18n.FromContext(r.Context()))
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// respondInvalid отвечает 400 со всеми нарушениями из validation.Errors на языке запроса.
+// Прочие ошибки отдаются как обычная ошибка 400 с их текстом.
+func respondInvalid(w http.ResponseWriter, r *http.Request, err error) {
+	var violations validation.Errors
+	if !errors.As(err, &violations) {
+		respondError(w, r, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+	lang := i18n.FromContext(r.Context())
+	respondProblem(w, r, Problem{
+		Type:   problemTypeValidation,
+		Code:   "validation_failed",
+		Title:  i18n.T(lang, "validation_failed"),
+		Status: http.StatusBadRequest,
+		Detail: i18n.T(lang, "validation_failed_detail", len(violations)),
+		Errors: violations.Localize(lang),
+	})
+}
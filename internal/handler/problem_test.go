@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestCreateSubscriptionInputViolations(t *testing.T) {
+	valid := CreateSubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       400,
+		UserID:      "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		StartDate:   "07-2025",
+	}
+	with := func(change func(*CreateSubscriptionInput)) CreateSubscriptionInput {
+		in := valid
+		change(&in)
+		return in
+	}
+	tests := []struct {
+		name string
+		in   CreateSubscriptionInput
+		want []string
+	}{
+		{"valid", valid, nil},
+		{"valid with optional fields", with(func(in *CreateSubscriptionInput) {
+			in.EndDate, in.TrialEndDate, in.BillingPeriod = strPtr("12-2025"), strPtr("08-2025"), "yearly"
+		}), nil},
+		{"empty input reports every required field", CreateSubscriptionInput{}, []string{
+			"/service_name required", "/user_id required", "/start_date required",
+		}},
+		{"all format errors at once", with(func(in *CreateSubscriptionInput) {
+			in.Price = -1
+			in.UserID = "not-a-uuid"
+			in.StartDate = "2025-07"
+			in.BillingPeriod = "weekly"
+		}), []string{
+			"/price too_small", "/user_id invalid_format", "/start_date invalid_format", "/billing_period not_allowed",
+		}},
+		{"too long name", with(func(in *CreateSubscriptionInput) { in.ServiceName = strings.Repeat("я", maxNameLength+1) }),
+			[]string{"/service_name too_long"}},
+		{"dates before start", with(func(in *CreateSubscriptionInput) {
+			in.EndDate, in.TrialEndDate = strPtr("06-2025"), strPtr("01-2025")
+		}), []string{"/end_date date_order", "/trial_end_date date_order"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.in.toSubscription()
+			var got []string
+			var errs validation.Errors
+			if errors.As(err, &errs) {
+				for _, v := range errs {
+					got = append(got, v.Pointer+" "+v.Code)
+				}
+			} else if err != nil {
+				t.Fatalf("toSubscription() error = %v, want validation.Errors", err)
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("violations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRespondInvalid(t *testing.T) {
+	var v validation.Validator
+	v.Required("/service_name", "")
+	v.Min("/price", -1, 0)
+
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", nil)
+	r = r.WithContext(i18n.WithLang(r.Context(), i18n.Russian))
+	rec := httptest.NewRecorder()
+	rec.Header().Set(requestIDHeader, "req-1")
+	respondInvalid(rec, r, v.Err())
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != problemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, problemContentType)
+	}
+	if lang := rec.Header().Get("Content-Language"); lang != i18n.Russian {
+		t.Errorf("Content-Language = %q, want ru", lang)
+	}
+	var p Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{
+		Type:      problemTypeValidation,
+		Code:      "validation_failed",
+		Title:     i18n.T(i18n.Russian, "validation_failed"),
+		Status:    http.StatusBadRequest,
+		Detail:    i18n.T(i18n.Russian, "validation_failed_detail", 2),
+		Instance:  "/subscriptions",
+		RequestID: "req-1",
+	}
+	errs := p.Errors
+	p.Errors = nil
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("problem = %+v\nwant %+v", p, want)
+	}
+	if len(errs) != 2 || errs[1].Pointer != "/price" || errs[1].Code != validation.CodeTooSmall ||
+		errs[1].Detail != i18n.T(i18n.Russian, "validation.too_small", 0) {
+		t.Errorf("errors = %+v", errs)
+	}
+}
+
+func TestRespondInvalidPlainError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	respondInvalid(rec, httptest.NewRequest(http.MethodPost, "/subscriptions", nil), errors.New("boom"))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("status = %d, Content-Type = %q; want a plain 400 error", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Code != "invalid_request" {
+		t.Errorf("response = %+v, %v; want code invalid_request", resp, err)
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// expensiveRoutes эндпоинты чтения, которые просматривают много строк: полный список подписок и агрегаты
+var expensiveRoutes = map[string]bool{
+	"/subscriptions":          true,
+	"/subscriptions/summary":  true,
+	"/subscriptions/forecast": true,
+	"/renewals":               true,
+}
+
+// rateLimitClass относит запрос к классу лимитов: изменения — write, тяжёлые выборки — expensive
+func rateLimitClass(r *http.Request) string {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return ratelimit.ClassWrite
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil && expensiveRoutes[tpl] {
+			return ratelimit.ClassExpensive
+		}
+	}
+	return ratelimit.ClassDefault
+}
+
+// rateLimitIPClass относит любой запрос к общему лимиту адреса клиента
+func rateLimitIPClass(*http.Request) string {
+	return ratelimit.ClassIP
+}
+
+// rateLimitIPKey определяет клиента по IP до аутентификации
+func rateLimitIPKey(trustForwardedFor bool) func(*http.Request) string {
+	return func(r *http.Request) string {
+		return "ip:" + clientIP(r, trustForwardedFor)
+	}
+}
+
+// rateLimitKey определяет клиента для лимитов: API-ключ, пользователь, а без аутентификации — IP
+func rateLimitKey(trustForwardedFor bool) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if p, ok := auth.FromContext(r.Context()); ok {
+			if p.IsService() {
+				return "key:" + p.KeyID.String()
+			}
+			return "user:" + p.UserID.String()
+		}
+		return "ip:" + clientIP(r, trustForwardedFor)
+	}
+}
+
+// clientIP возвращает адрес клиента; X-Forwarded-For учитывается, только если прокси доверенный.
+// Берётся последний адрес списка — его дописал сам доверенный прокси; адреса левее задаёт клиент,
+// и по ним он получал бы новую корзину на каждый запрос.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			xff := values[len(values)-1]
+			if i := strings.LastIndexByte(xff, ','); i >= 0 {
+				xff = xff[i+1:]
+			}
+			if ip := net.ParseIP(strings.TrimSpace(xff)); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		trust   bool
+		remote  string
+		forward []string
+		want    string
+	}{
+		{"remote address", false, "203.0.113.7:5123", nil, "203.0.113.7"},
+		{"forwarded header ignored without trust", false, "10.0.0.2:5123", []string{"198.51.100.1"}, "10.0.0.2"},
+		{"single forwarded address", true, "10.0.0.2:5123", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"client-supplied entries are skipped", true, "10.0.0.2:5123", []string{"1.2.3.4, 5.6.7.8, 198.51.100.1"}, "198.51.100.1"},
+		{"last header line", true, "10.0.0.2:5123", []string{"1.2.3.4", "198.51.100.1"}, "198.51.100.1"},
+		{"ipv6", true, "10.0.0.2:5123", []string{"1.2.3.4,2001:db8::1"}, "2001:db8::1"},
+		{"garbage falls back to remote address", true, "10.0.0.2:5123", []string{"1.2.3.4, not-an-ip"}, "10.0.0.2"},
+		{"empty last entry falls back to remote address", true, "10.0.0.2:5123", []string{"1.2.3.4,"}, "10.0.0.2"},
+		{"no header", true, "10.0.0.2:5123", nil, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.forward {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := clientIP(r, tt.trust); got != tt.want {
+				t.Errorf("clientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
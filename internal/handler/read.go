@@ -4,48 +4,95 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/export"
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
 // @Summary Получить все подписки
-// @Description Возвращает список всех подписок
+// @Description Возвращает список подписок: администратору — всех пользователей, остальным — только свои. Формат выбирается заголовком Accept: JSON, CSV, XLSX или NDJSON. JSON можно получать постранично: limit задаёт размер страницы, after — курсор из X-Next-Cursor
 // @Tags subscriptions
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Param limit query int false "Размер страницы JSON (1-1000), по умолчанию весь список"
+// @Param after query string false "Курсор: X-Next-Cursor предыдущей страницы"
 // @Success 200 {array} model.Subscription
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы, если она есть"
 // @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions [get]
 func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	if mediaType := h.negotiateExport(r); mediaType != export.MediaTypeJSON {
+		h.streamSubscriptions(w, r, h.visibleSubscriptions(r), mediaType)
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
 	var subs []model.Subscription
-	if err := h.DB.Find(&subs).Error; err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to fetch subscriptions")
+	if err := p.apply(h.visibleSubscriptions(r)).Find(&subs).Error; err != nil {
+		respondErr(w, r, err)
 		return
 	}
+	subs = p.trim(w, subs)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(subs)
 }
 
 // @Summary Получить подписки по user_id
-// @Description Возвращает список подписок определённого пользователя
+// @Description Возвращает список подписок определённого пользователя. Формат выбирается заголовком Accept: JSON, CSV, XLSX или NDJSON. JSON можно получать постранично: limit задаёт размер страницы, after — курсор из X-Next-Cursor
 // @Tags subscriptions
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
 // @Param user_id path string true "UUID пользователя"
+// @Param limit query int false "Размер страницы JSON (1-1000), по умолчанию весь список"
+// @Param after query string false "Курсор: X-Next-Cursor предыдущей страницы"
 // @Success 200 {array} model.Subscription
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы, если она есть"
 // @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{user_id} [get]
 func (h *Handler) GetSubscriptionsByUserID(w http.ResponseWriter, r *http.Request) {
 	userIDStr := mux.Vars(r)["user_id"]
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+	if !authorizeUser(w, r, userID) {
+		return
+	}
+	query := h.visibleSubscriptions(r).Where("user_id = ?", userID)
+	if mediaType := h.negotiateExport(r); mediaType != export.MediaTypeJSON {
+		h.streamSubscriptions(w, r, query, mediaType)
+		return
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
 		return
 	}
 	var subs []model.Subscription
-	if err := h.DB.Where("user_id = ?", userID).Find(&subs).Error; err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to fetch subscriptions")
+	if err := p.apply(query).Find(&subs).Error; err != nil {
+		respondErr(w, r, err)
 		return
 	}
+	subs = p.trim(w, subs)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(subs)
 }
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/billing"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultRenewalsWindow окно по умолчанию, если to не указан
+	defaultRenewalsWindow = 30 * 24 * time.Hour
+	// maxRenewalsWindowDays наибольшая длина окна в днях: списания раскладываются по каждой подписке
+	maxRenewalsWindowDays = 366
+)
+
+// RenewalsResponse предстоящие списания в окне
+type RenewalsResponse struct {
+	From     string           `json:"from" example:"2025-01-01"`
+	To       string           `json:"to" example:"2025-01-31"`
+	Charges  []billing.Charge `json:"charges"`
+	Total    int              `json:"total" example:"1198"`
+	Currency string           `json:"currency" example:"RUB"`
+}
+
+// parseWindowDate разбирает дату в формате YYYY-MM-DD или MM-YYYY (первое число месяца)
+func parseWindowDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse("01-2006", s)
+}
+
+// @Summary Предстоящие списания
+// @Description Раскладывает активные подписки в конкретные даты и суммы списаний в окне с учётом периодичности, пробного периода, пауз и даты окончания
+// @Tags renewals
+// @Produce json
+// @Param from query string false "Начало окна (YYYY-MM-DD или MM-YYYY), по умолчанию сегодня"
+// @Param to query string false "Конец окна (YYYY-MM-DD или MM-YYYY), по умолчанию from + 30 дней; не дальше 366 дней от from"
+// @Param user_id query string false "UUID пользователя"
+// @Success 200 {object} handler.RenewalsResponse
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 500 {object} handler.ErrorResponse "Internal Server Error"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /renewals [get]
+func (h *Handler) GetRenewals(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if s := q.Get("from"); s != "" {
+		t, err := parseWindowDate(s)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_from_date")
+			return
+		}
+		from = t
+	}
+	to := from.Add(defaultRenewalsWindow)
+	if s := q.Get("to"); s != "" {
+		t, err := parseWindowDate(s)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_to_date")
+			return
+		}
+		to = t
+	}
+	if to.Before(from) {
+		respondError(w, r, http.StatusBadRequest, "window_order")
+		return
+	}
+	if to.Sub(from) > maxRenewalsWindowDays*24*time.Hour {
+		respondError(w, r, http.StatusBadRequest, "window_too_long", maxRenewalsWindowDays)
+		return
+	}
+
+	query := h.visibleSubscriptions(r).Preload("Pauses").Preload("PriceChanges").
+		Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", to, from)
+	if userID := q.Get("user_id"); userID != "" {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+			return
+		}
+		if !authorizeUser(w, r, userUUID) {
+			return
+		}
+		query = query.Where("user_id = ?", userUUID)
+	}
+	var subs []model.Subscription
+	if err := query.Find(&subs).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	resp := RenewalsResponse{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Charges:  []billing.Charge{},
+		Currency: currentOrg(r).DefaultCurrency,
+	}
+	for _, sub := range subs {
+		for _, c := range billing.Charges(sub, from, to) {
+			resp.Charges = append(resp.Charges, c)
+			resp.Total += c.Amount
+		}
+	}
+	sort.SliceStable(resp.Charges, func(i, j int) bool {
+		if !resp.Charges[i].Date.Equal(resp.Charges[j].Date) {
+			return resp.Charges[i].Date.Before(resp.Charges[j].Date)
+		}
+		return resp.Charges[i].ServiceName < resp.Charges[j].ServiceName
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -1,25 +1,132 @@
 package handler
 
 import (
+	"net/http"
+
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"gorm.io/gorm"
 
 	_ "github.com/IlyaStarshinov/onlineSubscriptions/docs"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/health"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/metrics"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/ratelimit"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/tracing"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/webhook"
 )
 
-func SetupRouter(db *gorm.DB) *mux.Router {
-	h := NewHandler(db)
+// Deps зависимости роутера
+type Deps struct {
+	DB        *gorm.DB
+	Config    *config.Config
+	Readiness *health.Registry
+	// Metrics nil, если метрики отключены
+	Metrics *metrics.Metrics
+	// Auth nil, если аутентификация отключена
+	Auth    *auth.Authenticator
+	APIKeys *auth.APIKeys
+	// Policy решает, какие действия доступны ролям; nil — политика по умолчанию
+	Policy *auth.Policy
+	// RateLimit nil, если ограничение частоты запросов отключено
+	RateLimit *ratelimit.Limiter
+	// Webhooks nil, если вебхуки отключены
+	Webhooks *webhook.Webhooks
+}
+
+func SetupRouter(d Deps) *mux.Router {
+	h := NewHandler(d.DB, d.Config, d.Readiness)
+	h.APIKeys = d.APIKeys
+	h.Policy = d.Policy
+	h.Webhooks = d.Webhooks
+	if h.Policy == nil {
+		h.Policy = auth.DefaultPolicy()
+	}
+	policy := h.Policy
 	r := mux.NewRouter()
+	r.Use(requestIDMiddleware)
+	r.Use(tracing.Middleware)
+	// /metrics отдаётся отдельным внутренним сервером (metrics-addr), здесь только замеры запросов
+	if d.Metrics != nil {
+		r.Use(d.Metrics.Middleware)
+	}
 	r.Use(loggingMiddleware)
+	r.Use(i18n.Middleware(d.Config.I18n.DefaultLanguage))
+	if d.Config.Security.Headers {
+		r.Use(securityHeadersMiddleware(d.Config.Security))
+	}
+	if len(d.Config.CORS.AllowedOrigins) > 0 {
+		r.Use(corsMiddleware(d.Config.CORS))
+		// Регистрируется первым, чтобы preflight к любому пути дошёл до corsMiddleware
+		r.PathPrefix("/").Methods(http.MethodOptions).HandlerFunc(preflightFallback)
+	}
+	if d.Config.Compression.Enabled {
+		r.Use(compressMiddleware(d.Config.Compression))
+	}
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	r.HandleFunc("/healthz", h.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.Readyz).Methods("GET")
+	// Календарь открывается приложениями-календарями без заголовков, доступ по секретному токену в URL;
+	// организацию задаёт подписанный токен, потому что tenantMiddleware здесь не работает
+	r.HandleFunc("/users/{user_id}/renewals.ics", h.GetRenewalsCalendar).Methods("GET")
+
+	api := r.NewRoute().Subrouter()
+	// Лимит по IP проверяется до аутентификации: запросы с неверными учётными данными и поиск
+	// API-ключа в БД тоже расходуют его. Лимиты клиента по классам эндпоинтов — после, когда клиент известен.
+	if d.RateLimit != nil {
+		api.Use(d.RateLimit.Middleware(rateLimitIPClass, rateLimitIPKey(d.Config.RateLimit.TrustForwardedFor)))
+	}
+	if d.Auth != nil {
+		api.Use(d.Auth.Middleware)
+	}
+	if d.RateLimit != nil {
+		api.Use(d.RateLimit.Middleware(rateLimitClass, rateLimitKey(d.Config.RateLimit.TrustForwardedFor)))
+	}
+	api.Use(h.tenantMiddleware)
+	// Каждый маршрут относится к одному действию; политика решает, разрешено ли оно
+	// и над чьими подписками, обработчики только применяют это решение к выборке
+	read := policy.Require(auth.ActionRead)
+	write := policy.Require(auth.ActionWrite)
+	reports := policy.Require(auth.ActionReports)
+	admin := policy.Require(auth.ActionAdmin)
+
+	// Статические пути регистрируются раньше /subscriptions/{user_id}, иначе он их перехватывает
+	api.Handle("/subscriptions/summary", reports(http.HandlerFunc(h.GetSubscriptionSummary))).Methods("GET")
+	api.Handle("/subscriptions/forecast", reports(http.HandlerFunc(h.GetSubscriptionForecast))).Methods("GET")
+
+	api.Handle("/subscriptions/{id}", write(http.HandlerFunc(h.UpdateSubscription))).Methods("PUT")
+	api.Handle("/subscriptions", write(http.HandlerFunc(h.CreateSubscription))).Methods("POST")
+	api.Handle("/subscriptions", read(http.HandlerFunc(h.GetSubscription))).Methods("GET")
+	api.Handle("/subscriptions/{user_id}", read(http.HandlerFunc(h.GetSubscriptionsByUserID))).Methods("GET")
+	api.Handle("/subscriptions/{id}", write(http.HandlerFunc(h.DeleteSubscription))).Methods("DELETE")
+	api.Handle("/subscriptions/{id}/pauses", write(http.HandlerFunc(h.CreatePause))).Methods("POST")
+	api.Handle("/subscriptions/{id}/pauses", read(http.HandlerFunc(h.GetPauses))).Methods("GET")
+	api.Handle("/subscriptions/{id}/pauses/{pause_id}", write(http.HandlerFunc(h.DeletePause))).Methods("DELETE")
+	api.Handle("/subscriptions/{id}/price-changes", write(http.HandlerFunc(h.CreatePriceChange))).Methods("POST")
+	api.Handle("/subscriptions/{id}/price-changes", read(http.HandlerFunc(h.GetPriceChanges))).Methods("GET")
+	api.Handle("/subscriptions/{id}/price-changes/{change_id}", write(http.HandlerFunc(h.DeletePriceChange))).Methods("DELETE")
+	api.Handle("/renewals", reports(http.HandlerFunc(h.GetRenewals))).Methods("GET")
+	api.Handle("/users/{user_id}/renewals/link", read(http.HandlerFunc(h.GetRenewalsCalendarLink))).Methods("GET")
+
+	api.Handle("/organization", read(http.HandlerFunc(h.GetOrganization))).Methods("GET")
+	api.Handle("/organization", admin(http.HandlerFunc(h.UpdateOrganization))).Methods("PUT")
+	api.Handle("/organizations", admin(http.HandlerFunc(h.CreateOrganization))).Methods("POST")
+	api.Handle("/organizations", admin(http.HandlerFunc(h.GetOrganizations))).Methods("GET")
+
+	api.Handle("/api-keys", admin(http.HandlerFunc(h.CreateAPIKey))).Methods("POST")
+	api.Handle("/api-keys", admin(http.HandlerFunc(h.GetAPIKeys))).Methods("GET")
+	api.Handle("/api-keys/{id}/rotate", admin(http.HandlerFunc(h.RotateAPIKey))).Methods("POST")
+	api.Handle("/api-keys/{id}", admin(http.HandlerFunc(h.RevokeAPIKey))).Methods("DELETE")
 
-	r.HandleFunc("/subscriptions/{id}", h.UpdateSubscription).Methods("PUT")
-	r.HandleFunc("/subscriptions", h.CreateSubscription).Methods("POST")
-	r.HandleFunc("/subscriptions", h.GetSubscription).Methods("GET")
-	r.HandleFunc("/subscriptions/{user_id}", h.GetSubscriptionsByUserID).Methods("GET")
-	r.HandleFunc("/subscriptions/{id}", h.DeleteSubscription).Methods("DELETE")
-	r.HandleFunc("/subscriptions/summary", h.GetSubscriptionSummary).Methods("GET")
+	if h.Webhooks != nil {
+		api.Handle("/webhooks", admin(http.HandlerFunc(h.CreateWebhook))).Methods("POST")
+		api.Handle("/webhooks", admin(http.HandlerFunc(h.GetWebhooks))).Methods("GET")
+		api.Handle("/webhooks/deliveries", admin(http.HandlerFunc(h.GetWebhookDeliveries))).Methods("GET")
+		api.Handle("/webhooks/deliveries/{id}/redeliver", admin(http.HandlerFunc(h.RedeliverWebhook))).Methods("POST")
+		api.Handle("/webhooks/{id}", admin(http.HandlerFunc(h.DeleteWebhook))).Methods("DELETE")
+	}
 
 	return r
 }
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/health"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/metrics"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/ratelimit"
+)
+
+// TestMetricsAreNotServedOnAPIAddress проверяет, что метрики с расходами всех организаций
+// не отдаются роутером API: их публикует только внутренний сервер на metrics-addr
+func TestMetricsAreNotServedOnAPIAddress(t *testing.T) {
+	db, _ := dryRunDB(t)
+	m, err := metrics.New(db, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := SetupRouter(Deps{DB: db, Config: config.Default(), Readiness: health.NewRegistry(), Metrics: m})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /metrics on the API router: status = %d, want 404", rec.Code)
+	}
+}
+
+// TestFailedAuthenticationIsRateLimited проверяет, что лимит по IP стоит перед аутентификацией:
+// запросы с неверными учётными данными расходуют его и после исчерпания получают 429, а не 401
+func TestFailedAuthenticationIsRateLimited(t *testing.T) {
+	db, _ := dryRunDB(t)
+	cfg := config.Default()
+	cfg.Auth.HS256Secret = "secret"
+	cfg.RateLimit.IP = config.RateLimitRule{PerMinute: 1, Burst: 2}
+	router := SetupRouter(Deps{
+		DB:        db,
+		Config:    cfg,
+		Readiness: health.NewRegistry(),
+		Auth:      auth.NewAuthenticator(cfg.Auth, nil, auth.NewAPIKeys(db)),
+		RateLimit: ratelimit.New(cfg.RateLimit),
+	})
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		r.Header.Set("Authorization", "Bearer not-a-token")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i+1, rec.Code, want)
+		}
+		if rec.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want the IP limit 2", i+1, rec.Header().Get("RateLimit-Limit"))
+		}
+	}
+
+	// Другой адрес получает свою корзину
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	r.RemoteAddr = "198.51.100.9:4000"
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, r)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("request from another IP: status = %d, want 401", rec.Code)
+	}
+}
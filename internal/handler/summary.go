@@ -3,54 +3,87 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
 	"github.com/google/uuid"
 )
 
+// SummaryResponse сумма подписок в валюте организации
+type SummaryResponse struct {
+	TotalPrice int    `json:"total_price" example:"1198"`
+	Currency   string `json:"currency" example:"RUB"`
+}
+
 // @Summary Получить сумму подписок
-// @Description Выводит общую сумму подписок по фильтрам
+// @Description Выводит общую сумму подписок по фильтрам. Период задаётся start_date и end_date либо финансовым годом организации.
 // @Tags subscriptions
 // @Produce json
 // @Param user_id query string false "UUID пользователя"
 // @Param service_name query string false "Название сервиса"
-// @Param start_date query string true "Начало периода (MM-YYYY)"
-// @Param end_date query string true "Конец периода (MM-YYYY)"
-// @Success 200 {object} map[string]int
+// @Param start_date query string false "Начало периода (MM-YYYY), обязательно без fiscal_year"
+// @Param end_date query string false "Конец периода (MM-YYYY), обязательно без fiscal_year"
+// @Param fiscal_year query int false "Финансовый год организации, начинающийся в этом календарном году"
+// @Success 200 {object} handler.SummaryResponse
 // @Failure 400 {object} handler.ErrorResponse "Bad Request"
 // @Failure 500 {object} handler.ErrorResponse "Internal Server Error"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/summary [get]
 func (h *Handler) GetSubscriptionSummary(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	serviceName := r.URL.Query().Get("service_name")
 	startDateStr := r.URL.Query().Get("start_date")
 	endDateStr := r.URL.Query().Get("end_date")
+	fiscalYearStr := r.URL.Query().Get("fiscal_year")
+	org := currentOrg(r)
 
-	if startDateStr == "" || endDateStr == "" {
-		respondError(w, http.StatusBadRequest, "Missing start date or end date")
-		return
-	}
-	startDate, err := time.Parse("01-2006", startDateStr)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid start date")
-		return
-	}
-	endDate, err := time.Parse("01-2006", endDateStr)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid end date")
+	var startDate, endDate time.Time
+	var err error
+	switch {
+	case fiscalYearStr != "":
+		if startDateStr != "" || endDateStr != "" {
+			respondError(w, r, http.StatusBadRequest, "conflicting_period")
+			return
+		}
+		year, err := strconv.Atoi(fiscalYearStr)
+		if err != nil || year < 1 || year > 9999 {
+			respondError(w, r, http.StatusBadRequest, "invalid_fiscal_year")
+			return
+		}
+		startDate, endDate = org.FiscalYear(year)
+	case startDateStr == "" || endDateStr == "":
+		respondError(w, r, http.StatusBadRequest, "missing_period")
 		return
+	default:
+		startDate, err = time.Parse("01-2006", startDateStr)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_start_date")
+			return
+		}
+		endDate, err = time.Parse("01-2006", endDateStr)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_end_date")
+			return
+		}
 	}
 	var userUUID uuid.UUID
 	if userID != "" {
 		userUUID, err = uuid.Parse(userID)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid user ID")
+			respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+			return
+		}
+		if !authorizeUser(w, r, userUUID) {
 			return
 		}
 	}
 	var total int
-	query := h.DB.Model(&model.Subscription{}).
+	query := h.visibleSubscriptions(r).
 		Where("start_date >= ? AND start_date <= ?", startDate, endDate)
 	if userID != "" {
 		query = query.Where("user_id = ?", userUUID)
@@ -59,9 +92,9 @@ func (h *Handler) GetSubscriptionSummary(w http.ResponseWriter, r *http.Request)
 		query = query.Where("service_name = ?", serviceName)
 	}
 	if err := query.Select("SUM(price)").Scan(&total).Error; err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch subscription summary")
+		respondErr(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]int{"total_price": total})
+	json.NewEncoder(w).Encode(SummaryResponse{TotalPrice: total, Currency: org.DefaultCurrency})
 }
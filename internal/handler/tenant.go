@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+)
+
+// orgHeader заголовок, которым администратор платформы выбирает организацию
+const orgHeader = "X-Org-ID"
+
+type orgKey struct{}
+
+// currentOrg возвращает организацию запроса, определённую tenantMiddleware
+func currentOrg(r *http.Request) *model.Organization {
+	return r.Context().Value(orgKey{}).(*model.Organization)
+}
+
+// isPlatformAdmin сообщает, что вызывающая сторона не привязана к организации и имеет доступ admin,
+// то есть может выбирать организацию заголовком и управлять организациями.
+// Без аутентификации администратором платформы считается любой запрос.
+func (h *Handler) isPlatformAdmin(r *http.Request) bool {
+	p, ok := auth.FromContext(r.Context())
+	if !ok {
+		return true
+	}
+	return p.OrgID == uuid.Nil && h.Policy.Decide(p, auth.ActionAdmin) == auth.AccessAll
+}
+
+// tenantMiddleware определяет организацию запроса: из токена или API-ключа вызывающей стороны,
+// а для администратора платформы — из заголовка X-Org-ID. Без того и другого используется
+// организация по умолчанию. Все выборки обработчиков ограничиваются этой организацией.
+func (h *Handler) tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgID := model.DefaultOrgID
+		if p, ok := auth.FromContext(r.Context()); ok && p.OrgID != uuid.Nil {
+			orgID = p.OrgID
+		}
+		if header := r.Header.Get(orgHeader); header != "" {
+			requested, err := uuid.Parse(header)
+			if err != nil {
+				respondError(w, r, http.StatusBadRequest, "invalid_org_header", orgHeader)
+				return
+			}
+			if requested != orgID && !h.isPlatformAdmin(r) {
+				respondError(w, r, http.StatusForbidden, "foreign_org_denied")
+				return
+			}
+			orgID = requested
+		}
+
+		var org model.Organization
+		if err := h.db(r).First(&org, "id = ?", orgID).Error; err != nil {
+			respondErr(w, r, orNotFound(err, errOrganizationNotFound))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), orgKey{}, &org)))
+	})
+}
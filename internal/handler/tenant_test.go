@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/auth"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// statement запрос, построенный обработчиком
+type statement struct {
+	table string
+	sql   string
+}
+
+// dryRunDB открывает GORM в режиме DryRun: запросы строятся, но не выполняются, поэтому база не нужна.
+// Построенные запросы попадают в возвращаемый срез.
+func dryRunDB(t *testing.T) (*gorm.DB, *[]statement) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []statement
+	record := func(db *gorm.DB) {
+		statements = append(statements, statement{
+			table: db.Statement.Table,
+			sql:   db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...),
+		})
+	}
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Query().After("gorm:query").Register("test:record", record),
+		cb.Row().After("gorm:row").Register("test:record", record),
+		cb.Delete().After("gorm:delete").Register("test:record", record),
+		cb.Update().After("gorm:update").Register("test:record", record),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, &statements
+}
+
+// inOrg возвращает запрос, который tenantMiddleware отнёс к организации orgID
+func inOrg(orgID uuid.UUID, method, target string, vars map[string]string) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	r = mux.SetURLVars(r, vars)
+	org := &model.Organization{ID: orgID, DefaultCurrency: "RUB", FiscalYearStart: 1}
+	return r.WithContext(context.WithValue(r.Context(), orgKey{}, org))
+}
+
+// TestQueriesAreScopedToOrganization проверяет, что обработчики читают и удаляют подписки и вебхуки
+// только организации запроса: каждый запрос к этим таблицам ограничен её org_id, а id другой
+// организации, даже указанный в пути или параметрах, не даёт доступа к её записям.
+func TestQueriesAreScopedToOrganization(t *testing.T) {
+	orgA, orgB := uuid.New(), uuid.New()
+	db, statements := dryRunDB(t)
+	h := &Handler{
+		DB:       db,
+		Policy:   auth.DefaultPolicy(),
+		Webhooks: webhook.New(db, config.WebhooksConfig{Timeout: time.Second}),
+	}
+	foreignID := uuid.New().String()
+	requests := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     *http.Request
+	}{
+		{"list subscriptions", h.GetSubscription, inOrg(orgA, http.MethodGet, "/subscriptions", nil)},
+		{"list subscriptions page", h.GetSubscription, inOrg(orgA, http.MethodGet, "/subscriptions?limit=10", nil)},
+		{"subscriptions by user", h.GetSubscriptionsByUserID,
+			inOrg(orgA, http.MethodGet, "/subscriptions/"+foreignID, map[string]string{"user_id": foreignID})},
+		{"pauses of a subscription", h.GetPauses,
+			inOrg(orgA, http.MethodGet, "/subscriptions/"+foreignID+"/pauses", map[string]string{"id": foreignID})},
+		{"renewals", h.GetRenewals, inOrg(orgA, http.MethodGet, "/renewals", nil)},
+		{"list webhooks", h.GetWebhooks, inOrg(orgA, http.MethodGet, "/webhooks", nil)},
+		{"webhook deliveries", h.GetWebhookDeliveries,
+			inOrg(orgA, http.MethodGet, "/webhooks/deliveries?webhook_id="+orgB.String(), nil)},
+		{"delete webhook", h.DeleteWebhook,
+			inOrg(orgA, http.MethodDelete, "/webhooks/"+foreignID, map[string]string{"id": foreignID})},
+	}
+	scoped := map[string]bool{"subscriptions": true, "webhook_endpoints": true, "webhook_deliveries": true}
+	for _, tt := range requests {
+		t.Run(tt.name, func(t *testing.T) {
+			*statements = nil
+			tt.handler(httptest.NewRecorder(), tt.req)
+			checked := 0
+			for _, s := range *statements {
+				if !scoped[s.table] {
+					continue
+				}
+				checked++
+				if !strings.Contains(s.sql, "org_id = '"+orgA.String()+"'") {
+					t.Errorf("query is not limited to the request organization: %s", s.sql)
+				}
+			}
+			if checked == 0 {
+				t.Fatalf("handler built no queries to %v", scoped)
+			}
+		})
+	}
+}
+
+func TestForeignOrganizationHeaderIsDenied(t *testing.T) {
+	orgA, orgB := uuid.New(), uuid.New()
+	// DB не задана: запрос должен быть отклонён раньше, чем обработчик обратится к базе
+	h := &Handler{Policy: auth.DefaultPolicy()}
+	called := false
+	next := h.tenantMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+
+	principals := map[string]*auth.Principal{
+		"org admin":   {UserID: uuid.New(), Roles: []string{"admin"}, OrgID: orgA},
+		"org user":    {UserID: uuid.New(), Roles: []string{"editor"}, OrgID: orgA},
+		"org api key": {KeyID: uuid.New(), Scopes: []string{auth.ActionAdmin}, OrgID: orgA},
+	}
+	for name, p := range principals {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			r.Header.Set(orgHeader, orgB.String())
+			r = r.WithContext(auth.WithPrincipal(r.Context(), p))
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+			if rec.Code != http.StatusForbidden || called {
+				t.Errorf("status = %d, handler called = %v; want 403 without calling the handler", rec.Code, called)
+			}
+		})
+	}
+}
+
+func TestCalendarTokenIsBoundToOrganization(t *testing.T) {
+	orgA, orgB, userA, userB := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	h := &Handler{CalendarSecret: []byte("secret")}
+	token := h.calendarToken(orgA, userA)
+
+	if org, ok := h.calendarTokenOrg(token, userA); !ok || org != orgA {
+		t.Fatalf("calendarTokenOrg() = %s, %v; want %s, true", org, ok, orgA)
+	}
+	// Организация записана в токене открыто: заменить её на другую, не зная секрета, нельзя
+	forged := hex.EncodeToString(orgB[:]) + token[hex.EncodedLen(len(orgB)):]
+	other := (&Handler{CalendarSecret: []byte("other")}).calendarToken(orgB, userA)
+	tokens := map[string]struct {
+		token  string
+		userID uuid.UUID
+	}{
+		"other organization": {forged, userA},
+		"other user":         {token, userB},
+		"other secret":       {other, userA},
+		"truncated":          {token[:len(token)-2], userA},
+		"not hex":            {"zz" + token[2:], userA},
+		"signature only":     {token[hex.EncodedLen(len(orgA)):], userA},
+	}
+	for name, tt := range tokens {
+		t.Run(name, func(t *testing.T) {
+			// DB не задана: чужой токен должен отклоняться до обращения к базе
+			r := httptest.NewRequest(http.MethodGet, "/users/"+tt.userID.String()+"/renewals.ics?token="+tt.token, nil)
+			r = mux.SetURLVars(r, map[string]string{"user_id": tt.userID.String()})
+			rec := httptest.NewRecorder()
+			h.GetRenewalsCalendar(rec, r)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", rec.Code)
+			}
+		})
+	}
+}
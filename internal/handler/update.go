@@ -2,14 +2,13 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
-	"time"
 
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 // UpdateSubscriptionInput входные данные для обновления подписки
@@ -18,73 +17,95 @@ type UpdateSubscriptionInput struct {
 	Price       *int    `json:"price,omitempty" example:"399"`
 	StartDate   *string `json:"start_date,omitempty" example:"02-2023"`
 	EndDate     *string `json:"end_date,omitempty" example:"12-2023"`
+	// BillingPeriod monthly, quarterly, semiannual или yearly
+	BillingPeriod *string `json:"billing_period,omitempty" example:"yearly"`
+	// TrialEndDate пустая строка снимает пробный период
+	TrialEndDate *string `json:"trial_end_date,omitempty" example:"03-2023"`
+}
+
+// apply переносит переданные поля в подписку и проверяет их вместе с порядком итоговых дат.
+// Нарушения возвращаются все сразу в виде validation.Errors.
+func (in UpdateSubscriptionInput) apply(sub *model.Subscription) error {
+	var v validation.Validator
+	if in.ServiceName != nil {
+		if v.Required("/service_name", *in.ServiceName) {
+			v.MaxLength("/service_name", *in.ServiceName, maxNameLength)
+		}
+		sub.ServiceName = *in.ServiceName
+	}
+	if in.Price != nil {
+		v.Min("/price", *in.Price, 0)
+		sub.Price = *in.Price
+	}
+	if in.StartDate != nil {
+		sub.StartDate = v.Month("/start_date", *in.StartDate)
+	}
+	if in.EndDate != nil {
+		sub.EndDate = v.OptionalMonth("/end_date", in.EndDate)
+	}
+	if in.BillingPeriod != nil {
+		sub.BillingPeriod = model.BillingPeriod(*in.BillingPeriod)
+		v.Check(sub.BillingPeriod.Months() != 0, "/billing_period", validation.CodeNotAllowed, "validation.one_of", billingPeriods)
+	}
+	if in.TrialEndDate != nil {
+		sub.TrialEndDate = v.OptionalMonth("/trial_end_date", in.TrialEndDate)
+	}
+	checkSubscriptionDates(&v, sub)
+	return v.Err()
 }
 
 // @Summary Обновить подписку
-// @Description Обновляет поля существующей подписки
+// @Description Обновляет поля существующей подписки. Все нарушения во входных данных возвращаются сразу
+// @Description в формате application/problem+json (RFC 7807).
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param id path string true "UUID подписки"
 // @Param input body handler.UpdateSubscriptionInput true "Данные для обновления"
 // @Success 200 {object} model.Subscription
-// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 400 {object} handler.Problem "Bad Request"
 // @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [put]
 func (h *Handler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 	subIDStr := mux.Vars(r)["id"]
 	subID, err := uuid.Parse(subIDStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid subscription ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_subscription_id")
 		return
 	}
 
 	var input UpdateSubscriptionInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		log.Printf("Failed to decode request body: %v", err)
-		respondError(w, http.StatusBadRequest, "Invalid JSON")
+	if !h.decodeJSON(w, r, &input) {
 		return
 	}
 
 	var sub model.Subscription
-	if err := h.DB.First(&sub, "id = ?", subID).Error; err != nil {
-		respondError(w, http.StatusNotFound, "Subscription not found")
+	if err := h.visibleSubscriptions(r).First(&sub, "id = ?", subID).Error; err != nil {
+		respondErr(w, r, orNotFound(err, errSubscriptionNotFound))
 		return
 	}
 
-	if input.ServiceName != nil {
-		sub.ServiceName = *input.ServiceName
-	}
-	if input.Price != nil {
-		if *input.Price < 0 {
-			respondError(w, http.StatusBadRequest, "Price must be > 0")
-			return
-		}
-		sub.Price = *input.Price
-	}
-	if input.StartDate != nil {
-		t, err := time.Parse("01-2006", *input.StartDate)
-		if err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid start date format")
-			return
-		}
-		sub.StartDate = t
-	}
-	if input.EndDate != nil {
-		if *input.EndDate == "" {
-			sub.EndDate = nil
-		} else {
-			t, err := time.Parse("01-2006", *input.EndDate)
-			if err != nil {
-				respondError(w, http.StatusBadRequest, "Invalid end date format")
-				return
-			}
-			sub.EndDate = &t
-		}
+	if err := input.apply(&sub); err != nil {
+		respondInvalid(w, r, err)
+		return
 	}
 
-	if err := h.DB.Save(&sub).Error; err != nil {
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("Failed to update subscription: %v", err))
+	err = h.db(r).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&sub).Error; err != nil {
+			return err
+		}
+		return h.publish(tx, model.EventSubscriptionUpdated, sub)
+	})
+	if err != nil {
+		respondErr(w, r, err)
 		return
 	}
 
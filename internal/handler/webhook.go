@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// maxURLLength ограничение длины адреса вебхука
+const maxURLLength = 2048
+
+// deliveryStatuses допустимые значения фильтра status
+var deliveryStatuses = []string{model.DeliveryPending, model.DeliveryDelivered, model.DeliveryFailed}
+
+// CreateWebhookInput входные данные для регистрации вебхука
+type CreateWebhookInput struct {
+	// URL адрес http или https, на который отправляются события
+	URL string `json:"url" example:"https://notify.example.com/hooks/subscriptions"`
+	// Events subscription.created, subscription.updated, subscription.deleted,
+	// subscription.renewal_upcoming, subscription.ended
+	Events []string `json:"events" example:"subscription.created,subscription.deleted"`
+}
+
+// validate проверяет все поля запроса; нарушения возвращаются сразу в виде validation.Errors
+func (in CreateWebhookInput) validate() error {
+	var v validation.Validator
+	if v.Required("/url", in.URL) && v.MaxLength("/url", in.URL, maxURLLength) {
+		u, err := url.Parse(in.URL)
+		v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"/url", validation.CodeInvalidFormat, "validation.url")
+	}
+	if v.Check(len(in.Events) > 0, "/events", validation.CodeRequired, "validation.required") {
+		for i, event := range in.Events {
+			v.Check(slices.Contains(model.EventTypes, event), validation.Pointer("events", strconv.Itoa(i)),
+				validation.CodeNotAllowed, "validation.one_of", strings.Join(model.EventTypes, ", "))
+		}
+	}
+	return v.Err()
+}
+
+// WebhookWithSecret вебхук вместе с секретом подписи, который возвращается только при регистрации
+type WebhookWithSecret struct {
+	model.WebhookEndpoint
+	Secret string `json:"secret" example:"whsec_4f1c..."`
+}
+
+// publish записывает событие о подписке в транзакции tx, если вебхуки включены
+func (h *Handler) publish(tx *gorm.DB, event string, sub model.Subscription) error {
+	if h.Webhooks == nil {
+		return nil
+	}
+	return h.Webhooks.Publish(tx, event, sub)
+}
+
+// publishScheduleChange записывает subscription.updated после изменения пауз или цен подписки subID:
+// они меняют график списаний, поэтому событие несёт подписку, перечитанную в tx вместе с ними
+func (h *Handler) publishScheduleChange(tx *gorm.DB, subID uuid.UUID) error {
+	if h.Webhooks == nil {
+		return nil
+	}
+	var sub model.Subscription
+	err := tx.Preload("Pauses", func(db *gorm.DB) *gorm.DB { return db.Order("start_date") }).
+		Preload("PriceChanges", func(db *gorm.DB) *gorm.DB { return db.Order("effective_date") }).
+		First(&sub, "id = ?", subID).Error
+	if err != nil {
+		return err
+	}
+	return h.Webhooks.Publish(tx, model.EventSubscriptionUpdated, sub)
+}
+
+// @Summary Зарегистрировать вебхук
+// @Description Регистрирует адрес, на который POST-запросами доставляются события подписок организации.
+// @Description Тело запроса подписывается HMAC-SHA256: заголовок X-Webhook-Signature содержит "sha256=" и hex
+// @Description подписи строки "<X-Webhook-Timestamp>.<тело>" секретом, который возвращается один раз.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param input body handler.CreateWebhookInput true "Адрес и типы событий"
+// @Success 201 {object} handler.WebhookWithSecret
+// @Failure 400 {object} handler.Problem "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 413 {object} handler.ErrorResponse "Request Entity Too Large"
+// @Failure 415 {object} handler.ErrorResponse "Unsupported Media Type"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var input CreateWebhookInput
+	if !h.decodeJSON(w, r, &input) {
+		return
+	}
+	if err := input.validate(); err != nil {
+		respondInvalid(w, r, err)
+		return
+	}
+	endpoint, err := h.Webhooks.CreateEndpoint(r.Context(), currentOrg(r).ID, input.URL, input.Events)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "webhook registered", "webhook_id", endpoint.ID, "events", endpoint.Events)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(WebhookWithSecret{WebhookEndpoint: *endpoint, Secret: endpoint.Secret})
+}
+
+// @Summary Список вебхуков
+// @Description Возвращает вебхуки организации без секретов
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} model.WebhookEndpoint
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks [get]
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := h.Webhooks.ListEndpoints(r.Context(), currentOrg(r).ID)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoints)
+}
+
+// @Summary Удалить вебхук
+// @Description Удаляет вебхук вместе с историей его доставок; неотправленные события больше не доставляются
+// @Tags webhooks
+// @Param id path string true "UUID вебхука"
+// @Success 204
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_webhook_id")
+		return
+	}
+	if err := h.Webhooks.DeleteEndpoint(r.Context(), currentOrg(r).ID, id); err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "webhook deleted", "webhook_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Доставки вебхуков
+// @Description Возвращает доставки событий организации. status=failed — список недоставленных:
+// @Description доставки, исчерпавшие попытки; их можно отправить заново через redeliver.
+// @Tags webhooks
+// @Produce json
+// @Param status query string false "pending, delivered или failed"
+// @Param webhook_id query string false "UUID вебхука"
+// @Param limit query int false "Размер страницы, 1-1000; без него возвращается весь список"
+// @Param after query string false "Курсор: значение X-Next-Cursor предыдущей страницы"
+// @Success 200 {array} model.WebhookDelivery
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы, если она есть"
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks/deliveries [get]
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var filter webhook.DeliveryFilter
+	if s := q.Get("status"); s != "" {
+		if !slices.Contains(deliveryStatuses, s) {
+			respondError(w, r, http.StatusBadRequest, "invalid_delivery_status", strings.Join(deliveryStatuses, ", "))
+			return
+		}
+		filter.Status = s
+	}
+	if s := q.Get("webhook_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_webhook_id")
+			return
+		}
+		filter.EndpointID = id
+	}
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	var deliveries []model.WebhookDelivery
+	query := h.Webhooks.Deliveries(r.Context(), currentOrg(r).ID, filter)
+	if p.limit == 0 {
+		query = query.Order("created_at")
+	}
+	if err := p.apply(query).Find(&deliveries).Error; err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	deliveries = trimPage(w, p, deliveries, func(d model.WebhookDelivery) uuid.UUID { return d.ID })
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// @Summary Отправить доставку заново
+// @Description Ставит доставку в очередь с обнулённым счётчиком попыток: из списка недоставленных
+// @Description или повторно уже доставленную. Событие отправляется с тем же id и телом.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "UUID доставки"
+// @Success 202 {object} model.WebhookDelivery
+// @Failure 400 {object} handler.ErrorResponse "Bad Request"
+// @Failure 404 {object} handler.ErrorResponse "Not Found"
+// @Failure 401 {object} handler.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handler.ErrorResponse "Forbidden"
+// @Failure 429 {object} handler.ErrorResponse "Too Many Requests"
+// @Failure 503 {object} handler.ErrorResponse "Service Unavailable"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks/deliveries/{id}/redeliver [post]
+func (h *Handler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_delivery_id")
+		return
+	}
+	delivery, err := h.Webhooks.Redeliver(r.Context(), currentOrg(r).ID, id)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "webhook delivery requeued", "delivery_id", id, "event", delivery.Event)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
@@ -0,0 +1,110 @@
+// Package health собирает проверки готовности сервиса.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDraining возвращается проверкой готовности во время плавной остановки
+var ErrDraining = errors.New("server is shutting down")
+
+// checkTimeout ограничивает время одной проверки
+const checkTimeout = 2 * time.Second
+
+// Check проверка зависимости; nil означает, что зависимость в порядке
+type Check func(ctx context.Context) error
+
+// Result результат одной проверки
+type Result struct {
+	Status     string `json:"status" example:"ok"`
+	DurationMS int64  `json:"duration_ms" example:"3"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report сводный результат проверок готовности
+type Report struct {
+	Status string            `json:"status" example:"ok"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready сообщает, пройдены ли все проверки
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Registry набор проверок готовности
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// NewRegistry создаёт пустой набор проверок
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Add регистрирует проверку под именем name
+func (r *Registry) Add(name string, c Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, namedCheck{name, c})
+}
+
+// SetDraining переводит готовность в состояние отказа на время плавной остановки
+func (r *Registry) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Run выполняет все проверки параллельно
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]namedCheck(nil), r.checks...)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks)+1)}
+	if r.draining.Load() {
+		report.Status = StatusUnavailable
+		report.Checks["shutdown"] = Result{Status: StatusUnavailable, Error: ErrDraining.Error()}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			start := time.Now()
+			err := c.check(checkCtx)
+			res := Result{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				res.Status = StatusUnavailable
+				res.Error = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = res
+			if err != nil {
+				report.Status = StatusUnavailable
+			}
+		}(c)
+	}
+	wg.Wait()
+	return report
+}
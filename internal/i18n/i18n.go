@@ -0,0 +1,128 @@
+// Package i18n хранит каталог сообщений API на поддерживаемых языках и выбирает язык запроса.
+// Ключ сообщения совпадает с машиночитаемым кодом ошибки, поэтому код не зависит от языка ответа.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Поддерживаемые языки
+const (
+	English = "en"
+	Russian = "ru"
+)
+
+// Languages языки, для которых есть каталог
+var Languages = []string{English, Russian}
+
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// catalog сообщения по языкам; заполняется из locales при запуске
+var catalog = mustLoad()
+
+func mustLoad() map[string]map[string]string {
+	c := make(map[string]map[string]string, len(Languages))
+	for _, lang := range Languages {
+		data, err := locales.ReadFile(path.Join("locales", lang+".yaml"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: read %s catalog: %v", lang, err))
+		}
+		messages := map[string]string{}
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: parse %s catalog: %v", lang, err))
+		}
+		c[lang] = messages
+	}
+	return c
+}
+
+// Supported сообщает, что для языка есть каталог
+func Supported(lang string) bool {
+	return slices.Contains(Languages, lang)
+}
+
+// T возвращает сообщение key на языке lang, подставляя args по правилам fmt.
+// Если перевода нет, используется английский, а без него — сам ключ.
+func T(lang, key string, args ...any) string {
+	msg, ok := catalog[lang][key]
+	if !ok {
+		msg, ok = catalog[English][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Negotiate выбирает поддерживаемый язык из заголовка Accept-Language с учётом q.
+// Региональные варианты (ru-RU) сводятся к основному языку. Пустая строка — подходящего языка нет.
+func Negotiate(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if q > 0 && Supported(primary) {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	// Стабильная сортировка сохраняет порядок клиента при равных q
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0].lang
+}
+
+type langKey struct{}
+
+// WithLang сохраняет язык ответа в контексте
+func WithLang(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// FromContext возвращает язык ответа из контекста, по умолчанию английский
+func FromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(langKey{}).(string); ok {
+		return lang
+	}
+	return English
+}
+
+// Middleware определяет язык ответа по Accept-Language, а без подходящего — берёт defaultLang.
+// Предпочтение пользователя из токена применяет позже middleware аутентификации.
+func Middleware(defaultLang string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Language")
+			lang := Negotiate(r.Header.Get("Accept-Language"))
+			if lang == "" {
+				lang = defaultLang
+			}
+			next.ServeHTTP(w, r.WithContext(WithLang(r.Context(), lang)))
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package i18n
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// verbPattern находит глаголы fmt в сообщении, включая флаги и ширину
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z%]`)
+
+// TestCatalogParity проверяет, что каталоги всех языков содержат одни и те же ключи
+// и одинаковые плейсхолдеры: иначе перевод потеряет аргумент или выведет %!d(MISSING)
+func TestCatalogParity(t *testing.T) {
+	reference := catalog[English]
+	if len(reference) == 0 {
+		t.Fatal("English catalog is empty")
+	}
+	for _, lang := range Languages {
+		messages := catalog[lang]
+		for key, msg := range reference {
+			translated, ok := messages[key]
+			if !ok {
+				t.Errorf("%s: missing key %q", lang, key)
+				continue
+			}
+			if strings.TrimSpace(translated) == "" {
+				t.Errorf("%s: empty message for %q", lang, key)
+			}
+			want, got := verbPattern.FindAllString(msg, -1), verbPattern.FindAllString(translated, -1)
+			if !slices.Equal(got, want) {
+				t.Errorf("%s: %q has placeholders %v, want %v as in en", lang, key, got, want)
+			}
+		}
+		for key := range messages {
+			if _, ok := reference[key]; !ok {
+				t.Errorf("%s: key %q is not in the English catalog", lang, key)
+			}
+		}
+	}
+}
+
+// codePattern находит коды сообщений, которые код сервиса передаёт в каталог
+var codePattern = regexp.MustCompile(`(?:respondError|WriteError)\([^"\n]*"([a-z_]+)"|"(validation\.[a-z_]+)"`)
+
+// TestCatalogCoversCodes проверяет, что у каждого кода ошибки в исходниках есть сообщение:
+// без него T вернёт клиенту сам ключ
+func TestCatalogCoversCodes(t *testing.T) {
+	root := filepath.Join("..", "..")
+	found := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "vendor" || d.Name() == "docs" || strings.HasPrefix(d.Name(), ".")) && path != root {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range codePattern.FindAllStringSubmatch(string(src), -1) {
+			code := m[1] + m[2]
+			found++
+			if _, ok := catalog[English][code]; !ok {
+				t.Errorf("%s: code %q has no message in the catalog", path, code)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == 0 {
+		t.Fatal("no message codes found in the sources")
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		args []any
+		want string
+	}{
+		{"english", English, "body_too_large", []any{1024}, "Request body exceeds 1024 bytes"},
+		{"russian", Russian, "rate_limited", nil, catalog[Russian]["rate_limited"]},
+		{"unsupported language falls back to english", "de", "invalid_json", nil, "Invalid JSON"},
+		{"unknown key is returned as is", Russian, "no_such_key", nil, "no_such_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Errorf("T(%s, %s) = %q, want %q", tt.lang, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"ru", Russian},
+		{"ru-RU,ru;q=0.9,en;q=0.8", Russian},
+		{"de, en;q=0.5", English},
+		{"en;q=0.5, ru;q=0.9", Russian},
+		{"en, ru", English},
+		{"ru;q=0, en;q=0.1", English},
+		{"de, fr", ""},
+		{"EN-us", English},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"ru-RU", Russian},
+		{"de", Russian},
+		{"", Russian},
+		{"en", English},
+	}
+	for _, tt := range tests {
+		var got string
+		handler := Middleware(Russian)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = FromContext(r.Context())
+		}))
+		r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept-Language", tt.accept)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		if got != tt.want {
+			t.Errorf("Accept-Language %q: language = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+	if got := FromContext(context.Background()); got != English {
+		t.Errorf("FromContext() without a language = %q, want en", got)
+	}
+}
@@ -0,0 +1,32 @@
+package i18n
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RequestIDHeader заголовок с идентификатором запроса; его выставляет первый middleware сервиса
+const RequestIDHeader = "X-Request-ID"
+
+// ErrorResponse стандартный ответ с ошибкой
+type ErrorResponse struct {
+	// Code машиночитаемый код ошибки, не зависит от языка ответа
+	Code string `json:"code" example:"subscription_not_found"`
+	// Error сообщение на языке из Accept-Language или предпочтения пользователя
+	Error     string `json:"error" example:"Subscription not found"`
+	RequestID string `json:"request_id,omitempty" example:"6f1c2d4e-8a9b-4c3d-9e0f-1a2b3c4d5e6f"`
+}
+
+// WriteError отправляет ошибку с кодом code и сообщением из каталога на языке запроса.
+// Им отвечают все слои сервиса: обработчики, аутентификация и ограничение частоты запросов.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code string, args ...any) {
+	lang := FromContext(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Code:      code,
+		Error:     T(lang, code, args...),
+		RequestID: w.Header().Get(RequestIDHeader),
+	})
+}
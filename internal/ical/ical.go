@@ -0,0 +1,108 @@
+// Package ical формирует календари в формате iCalendar (RFC 5545).
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+	maxLineOctets  = 75
+)
+
+// Event описывает повторяющееся событие на весь день
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	// RRule правило повторения без префикса RRULE:, например FREQ=MONTHLY
+	RRule string
+	// ExDates даты, исключённые из повторения
+	ExDates []time.Time
+}
+
+// Calendar календарь с набором событий
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Encode записывает календарь в w с переносом длинных строк и окончаниями CRLF
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(dateTimeFormat)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+c.ProdID)
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+	for _, e := range c.Events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+e.UID)
+		writeLine(bw, "DTSTAMP:"+stamp)
+		writeLine(bw, "DTSTART;VALUE=DATE:"+e.Start.Format(dateFormat))
+		if e.RRule != "" {
+			writeLine(bw, "RRULE:"+e.RRule)
+		}
+		for _, d := range e.ExDates {
+			writeLine(bw, "EXDATE;VALUE=DATE:"+d.Format(dateFormat))
+		}
+		writeLine(bw, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		writeLine(bw, "TRANSP:TRANSPARENT")
+		writeLine(bw, "END:VEVENT")
+	}
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// FormatDate форматирует дату для параметров правила повторения (UNTIL)
+func FormatDate(t time.Time) string {
+	return t.Format(dateFormat)
+}
+
+// writeLine пишет строку контента, перенося её по 75 октетов без разрыва UTF-8 символов
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// продолжение начинается с пробела, который тоже входит в лимит
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,118 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Netflix", "Netflix"},
+		{"Price: 400; monthly", `Price: 400\; monthly`},
+		{"a,b", `a\,b`},
+		{`C:\path`, `C:\\path`},
+		{"line1\nline2", `line1\nline2`},
+		{"line1\r\nline2", `line1\nline2`},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteLineFolding(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Netflix"},
+		{"exactly 75 octets", "DESCRIPTION:" + strings.Repeat("a", 63)},
+		{"long ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"long cyrillic", "SUMMARY:" + strings.Repeat("Подписка Яндекс Плюс ", 10)},
+		{"long emoji", "SUMMARY:" + strings.Repeat("🎵", 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			bw := bufio.NewWriter(&buf)
+			writeLine(bw, tt.line)
+			bw.Flush()
+
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("line does not end with CRLF: %q", out)
+			}
+			physical := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, l := range physical {
+				if len(l) > maxLineOctets {
+					t.Errorf("line %d is %d octets, limit %d", i, len(l), maxLineOctets)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 character: %q", i, l)
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, l)
+				}
+			}
+			// Развёртка по RFC 5545: удалить CRLF вместе со следующим пробелом
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	cal := Calendar{
+		ProdID: "-//test//EN",
+		Name:   "Renewals, test",
+		Events: []Event{{
+			UID:         "sub-1@test",
+			Summary:     "Renewal: Netflix",
+			Description: "Price: 400 (monthly)",
+			Start:       time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			RRule:       "FREQ=MONTHLY;UNTIL=" + FormatDate(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)),
+			ExDates:     []time.Time{time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+	}
+	var buf bytes.Buffer
+	if err := cal.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	want := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//test//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Renewals\, test`,
+		"BEGIN:VEVENT",
+		"UID:sub-1@test",
+		"DTSTAMP:",
+		"DTSTART;VALUE=DATE:20250701",
+		"RRULE:FREQ=MONTHLY;UNTIL=20251201",
+		"EXDATE;VALUE=DATE:20250901",
+		"SUMMARY:Renewal: Netflix",
+		"DESCRIPTION:Price: 400 (monthly)",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i := range want {
+		// DTSTAMP содержит текущее время, поэтому проверяется только префикс
+		if !strings.HasPrefix(lines[i], want[i]) || (want[i] != "DTSTAMP:" && lines[i] != want[i]) {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
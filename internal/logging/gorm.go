@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold запросы дольше этого порога пишутся с уровнем warn
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger направляет журнал GORM в slog: запросы с длительностью на уровне debug,
+// медленные запросы на warn, ошибки (кроме «запись не найдена») на error
+type GormLogger struct {
+	logger *slog.Logger
+}
+
+// NewGormLogger создаёт адаптер журнала GORM поверх l
+func NewGormLogger(l *slog.Logger) *GormLogger {
+	return &GormLogger{logger: l}
+}
+
+// LogMode оставлен для совместимости с logger.Interface: уровень задаётся настройками slog
+func (g *GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return g
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	g.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	g.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	g.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		g.logger.ErrorContext(ctx, "db query failed",
+			"sql", sql, "rows", rows, "duration", elapsed, "error", err)
+	case elapsed > slowQueryThreshold:
+		sql, rows := fc()
+		g.logger.WarnContext(ctx, "slow db query",
+			"sql", sql, "rows", rows, "duration", elapsed)
+	case g.logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		g.logger.DebugContext(ctx, "db query",
+			"sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
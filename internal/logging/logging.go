@@ -0,0 +1,70 @@
+// Package logging настраивает структурированный журнал slog и связывает записи с запросом.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey struct{}
+
+// New создаёт логгер по настройкам: формат text или json и минимальный уровень.
+// Записи с контекстом запроса автоматически получают атрибуты request_id, trace_id и span_id.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+	var h slog.Handler
+	if cfg.Format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// ParseLevel переводит имя уровня в slog.Level; неизвестное имя даёт info
+func ParseLevel(s string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(s))); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// WithRequestID сохраняет идентификатор запроса в контексте
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// contextHandler добавляет request_id и идентификаторы трассы из контекста к каждой записи
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
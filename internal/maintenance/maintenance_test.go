@@ -0,0 +1,183 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunDB открывает GORM в режиме DryRun: запросы строятся, но не выполняются, поэтому база не нужна.
+// Построенные запросы попадают в возвращаемый срез.
+func dryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	record := func(db *gorm.DB) {
+		statements = append(statements, db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...))
+	}
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Query().After("gorm:query").Register("test:record", record),
+		cb.Row().After("gorm:row").Register("test:record", record),
+		cb.Delete().After("gorm:delete").Register("test:record", record),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, &statements
+}
+
+func TestChecks(t *testing.T) {
+	tables := map[string]bool{}
+	db, _ := dryRunDB(t)
+	for _, m := range []any{&model.Subscription{}, &model.SubscriptionPause{}, &model.PriceChange{}, &model.APIKey{}} {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			t.Fatal(err)
+		}
+		tables[stmt.Schema.Table] = true
+	}
+	names := map[string]bool{}
+	for _, c := range checks {
+		if names[c.name] {
+			t.Errorf("duplicate check name %s", c.name)
+		}
+		names[c.name] = true
+		if !tables[c.table] {
+			t.Errorf("%s: unknown table %s", c.name, c.table)
+		}
+		if c.description == "" || c.where == "" {
+			t.Errorf("%s: description and condition are required", c.name)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	db, statements := dryRunDB(t)
+	report, err := Verify(context.Background(), db, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || len(report.Checks) != len(checks) {
+		t.Errorf("report = %+v, want %d passed checks", report, len(checks))
+	}
+	// Без нарушений выполняется только подсчёт, по одному запросу на проверку
+	if len(*statements) != len(checks) {
+		t.Fatalf("Verify() built %d queries, want %d", len(*statements), len(checks))
+	}
+	for i, c := range checks {
+		want := fmt.Sprintf("SELECT count(*) FROM %q WHERE %s", c.table, c.where)
+		if (*statements)[i] != want {
+			t.Errorf("%s:\n got %s\nwant %s", c.name, (*statements)[i], want)
+		}
+	}
+}
+
+func TestReportOK(t *testing.T) {
+	if !(Report{}).OK() {
+		t.Error("empty report is not OK")
+	}
+	if (Report{Issues: 1}).OK() {
+		t.Error("report with issues is OK")
+	}
+}
+
+func TestMonthAligned(t *testing.T) {
+	want := "end_date = date_trunc('month', end_date AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'"
+	if got := monthAligned("end_date"); got != want {
+		t.Errorf("monthAligned() = %q, want %q", got, want)
+	}
+}
+
+func TestPlanRenames(t *testing.T) {
+	orgA := uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	orgB := uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	tests := []struct {
+		name     string
+		variants []nameVariant
+		want     []string
+	}{
+		{
+			name:     "single clean spelling",
+			variants: []nameVariant{{orgA, "Netflix", 3}},
+		},
+		{
+			name:     "most frequent spelling wins",
+			variants: []nameVariant{{orgA, "netflix", 5}, {orgA, "Netflix", 2}, {orgA, " NETFLIX ", 1}},
+			want:     []string{`a: " NETFLIX " -> "netflix" (1)`, `a: "Netflix" -> "netflix" (2)`},
+		},
+		{
+			name:     "single spelling with extra spaces is cleaned",
+			variants: []nameVariant{{orgA, "  Yandex   Plus ", 4}},
+			want:     []string{`a: "  Yandex   Plus " -> "Yandex Plus" (4)`},
+		},
+		{
+			name:     "clean spelling wins a tie",
+			variants: []nameVariant{{orgA, "spotify ", 2}, {orgA, "Spotify", 2}},
+			want:     []string{`a: "spotify " -> "Spotify" (2)`},
+		},
+		{
+			name:     "alphabetical order breaks a tie of clean spellings",
+			variants: []nameVariant{{orgA, "kion", 1}, {orgA, "Kion", 1}},
+			want:     []string{`a: "kion" -> "Kion" (1)`},
+		},
+		{
+			name:     "organizations are normalized separately",
+			variants: []nameVariant{{orgB, "netflix", 3}, {orgB, "Netflix", 1}, {orgA, "Netflix", 3}, {orgA, "netflix", 1}},
+			want:     []string{`a: "netflix" -> "Netflix" (1)`, `b: "Netflix" -> "netflix" (1)`},
+		},
+		{
+			name:     "different services are not merged",
+			variants: []nameVariant{{orgA, "Netflix", 1}, {orgA, "Netflix Premium", 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range planRenames(tt.variants) {
+				org := strings.TrimLeft(r.OrgID.String()[24:], "0")
+				got = append(got, fmt.Sprintf("%s: %q -> %q (%d)", org, r.From, r.To, r.Rows))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("planRenames():\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPurgeAPIKeys(t *testing.T) {
+	before := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		dryRun bool
+		want   string
+	}{
+		{true, `SELECT count(*) FROM "api_keys" WHERE revoked_at < '2025-06-01 00:00:00' OR expires_at < '2025-06-01 00:00:00'`},
+		{false, `DELETE FROM "api_keys" WHERE revoked_at < '2025-06-01 00:00:00' OR expires_at < '2025-06-01 00:00:00'`},
+	}
+	for _, tt := range tests {
+		db, statements := dryRunDB(t)
+		if _, err := PurgeAPIKeys(context.Background(), db, before, tt.dryRun); err != nil {
+			t.Fatal(err)
+		}
+		if len(*statements) != 1 || (*statements)[0] != tt.want {
+			t.Errorf("PurgeAPIKeys(dryRun %v) built %q, want %q", tt.dryRun, *statements, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Rename переименование сервиса в подписках одной организации
+type Rename struct {
+	OrgID uuid.UUID `json:"org_id"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Rows  int64     `json:"rows"`
+}
+
+// serviceNameKey ключ сравнения названий: без лишних пробелов и без учёта регистра
+func serviceNameKey(name string) string {
+	return strings.ToLower(cleanServiceName(name))
+}
+
+// cleanServiceName убирает пробелы по краям и повторные пробелы внутри названия
+func cleanServiceName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// nameVariant написание названия сервиса в организации и число подписок с ним
+type nameVariant struct {
+	OrgID       uuid.UUID
+	ServiceName string
+	Rows        int64
+}
+
+// NormalizeServiceNames сводит написания одного сервиса в организации к одному: «netflix », «NETFLIX»
+// и «Netflix» становятся самым частым из них без лишних пробелов. Фильтр service_name в сводке
+// и прогнозе сравнивает названия точно, поэтому разные написания дают разные суммы.
+// При dryRun только возвращает план переименований.
+func NormalizeServiceNames(ctx context.Context, db *gorm.DB, dryRun bool) ([]Rename, error) {
+	var variants []nameVariant
+	err := db.WithContext(ctx).Table("subscriptions").
+		Select("org_id, service_name, count(*) AS rows").
+		Group("org_id, service_name").
+		Scan(&variants).Error
+	if err != nil {
+		return nil, fmt.Errorf("load service names: %w", err)
+	}
+
+	renames := planRenames(variants)
+	if dryRun || len(renames) == 0 {
+		return renames, nil
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, r := range renames {
+			res := tx.Table("subscriptions").
+				Where("org_id = ? AND service_name = ?", r.OrgID, r.From).
+				Update("service_name", r.To)
+			if res.Error != nil {
+				return fmt.Errorf("rename %q to %q: %w", r.From, r.To, res.Error)
+			}
+			renames[i].Rows = res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renames, nil
+}
+
+// planRenames группирует написания по организации и ключу сравнения и переименовывает каждое
+// в самое частое написание группы; при равенстве предпочитается уже чистое, затем по алфавиту
+func planRenames(variants []nameVariant) []Rename {
+	type group struct {
+		orgID uuid.UUID
+		names []string
+		rows  map[string]int64
+	}
+	groups := map[string]*group{}
+	for _, v := range variants {
+		key := v.OrgID.String() + "/" + serviceNameKey(v.ServiceName)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{orgID: v.OrgID, rows: map[string]int64{}}
+			groups[key] = g
+		}
+		g.names = append(g.names, v.ServiceName)
+		g.rows[v.ServiceName] = v.Rows
+	}
+
+	var renames []Rename
+	for _, g := range groups {
+		sort.Slice(g.names, func(i, j int) bool {
+			a, b := g.names[i], g.names[j]
+			if g.rows[a] != g.rows[b] {
+				return g.rows[a] > g.rows[b]
+			}
+			if ca, cb := a == cleanServiceName(a), b == cleanServiceName(b); ca != cb {
+				return ca
+			}
+			return a < b
+		})
+		canonical := cleanServiceName(g.names[0])
+		for _, name := range g.names {
+			if name != canonical {
+				renames = append(renames, Rename{OrgID: g.orgID, From: name, To: canonical, Rows: g.rows[name]})
+			}
+		}
+	}
+	sort.Slice(renames, func(i, j int) bool {
+		if renames[i].OrgID != renames[j].OrgID {
+			return renames[i].OrgID.String() < renames[j].OrgID.String()
+		}
+		if renames[i].To != renames[j].To {
+			return renames[i].To < renames[j].To
+		}
+		return renames[i].From < renames[j].From
+	})
+	return renames
+}
+
+// dateColumns колонки дат, которые API сохраняет первым числом месяца
+var dateColumns = []struct{ table, column string }{
+	{"subscriptions", "start_date"},
+	{"subscriptions", "end_date"},
+	{"subscriptions", "trial_end_date"},
+	{"subscription_pauses", "start_date"},
+	{"subscription_pauses", "end_date"},
+	{"price_changes", "effective_date"},
+}
+
+// DateFix число исправленных значений в одной колонке
+type DateFix struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Rows   int64  `json:"rows"`
+}
+
+// NormalizeDates приводит даты подписок, пауз и изменений цены к первому числу месяца в UTC,
+// как их сохраняет API. Даты, записанные в обход API, иначе сдвигают расписание списаний:
+// 31 января плюс месяц — это 2 марта. При dryRun только считает такие значения.
+func NormalizeDates(ctx context.Context, db *gorm.DB, dryRun bool) ([]DateFix, error) {
+	fixes := make([]DateFix, 0, len(dateColumns))
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, c := range dateColumns {
+			fix := DateFix{Table: c.table, Column: c.column}
+			truncated := fmt.Sprintf("date_trunc('month', %s AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'", c.column)
+			query := tx.Table(c.table).Where(fmt.Sprintf("%s <> %s", c.column, truncated))
+			if dryRun {
+				if err := query.Count(&fix.Rows).Error; err != nil {
+					return fmt.Errorf("count %s.%s: %w", c.table, c.column, err)
+				}
+			} else {
+				res := query.Update(c.column, gorm.Expr(truncated))
+				if res.Error != nil {
+					return fmt.Errorf("normalize %s.%s: %w", c.table, c.column, res.Error)
+				}
+				fix.Rows = res.RowsAffected
+			}
+			fixes = append(fixes, fix)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fixes, nil
+}
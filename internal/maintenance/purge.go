@@ -0,0 +1,30 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"gorm.io/gorm"
+)
+
+// PurgeAPIKeys удаляет API-ключи, отозванные или истёкшие раньше before. Это единственные
+// мягко удалённые записи в схеме: подписки, паузы и изменения цены API удаляет сразу,
+// а отозванный ключ остаётся в таблице, чтобы его было видно в GET /api-keys.
+// При dryRun только считает такие ключи.
+func PurgeAPIKeys(ctx context.Context, db *gorm.DB, before time.Time, dryRun bool) (int64, error) {
+	query := db.WithContext(ctx).Where("revoked_at < ? OR expires_at < ?", before, before)
+	if dryRun {
+		var n int64
+		if err := query.Model(&model.APIKey{}).Count(&n).Error; err != nil {
+			return 0, fmt.Errorf("count API keys: %w", err)
+		}
+		return n, nil
+	}
+	res := query.Delete(&model.APIKey{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("purge API keys: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,102 @@
+// Package maintenance обслуживает данные напрямую в базе, в обход API: проверяет целостность,
+// нормализует значения и удаляет отозванные записи. Используется командой subadmin.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"gorm.io/gorm"
+)
+
+// check одна проверка целостности: записи таблицы Table, удовлетворяющие Where, нарушают правило
+type check struct {
+	name        string
+	description string
+	table       string
+	where       string
+}
+
+// monthAligned условие «дата — первое число месяца в UTC», так даты сохраняет API
+func monthAligned(col string) string {
+	return fmt.Sprintf("%[1]s = date_trunc('month', %[1]s AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'", col)
+}
+
+// checks проверки целостности. Пользователей в базе нет, user_id приходит из токена,
+// поэтому «осиротевшие» записи ищутся по ссылкам на организации и подписки.
+var checks = []check{
+	{"subscription_end_before_start", "subscription end_date is before start_date", "subscriptions",
+		"end_date < start_date"},
+	{"subscription_trial_before_start", "subscription trial_end_date is before start_date", "subscriptions",
+		"trial_end_date < start_date"},
+	{"subscription_negative_price", "subscription price is negative", "subscriptions",
+		"price < 0"},
+	{"subscription_unknown_billing_period", "subscription billing_period is not one of monthly, quarterly, semiannual, yearly", "subscriptions",
+		fmt.Sprintf("billing_period NOT IN ('%s', '%s', '%s', '%s')",
+			model.BillingMonthly, model.BillingQuarterly, model.BillingSemiAnnual, model.BillingYearly)},
+	{"subscription_empty_service_name", "subscription service_name is blank", "subscriptions",
+		"btrim(service_name) = ''"},
+	{"subscription_nil_user", "subscription belongs to the nil user UUID", "subscriptions",
+		"user_id = '00000000-0000-0000-0000-000000000000'"},
+	{"subscription_orphaned_org", "subscription references a missing organization", "subscriptions",
+		"NOT EXISTS (SELECT 1 FROM organizations o WHERE o.id = subscriptions.org_id)"},
+	{"subscription_unaligned_dates", "subscription dates are not the first day of a month (fix with normalize-dates)", "subscriptions",
+		"NOT (" + monthAligned("start_date") + ") OR NOT (" + monthAligned("end_date") + ") OR NOT (" + monthAligned("trial_end_date") + ")"},
+	{"pause_end_before_start", "pause end_date is before start_date", "subscription_pauses",
+		"end_date < start_date"},
+	{"pause_unaligned_dates", "pause dates are not the first day of a month (fix with normalize-dates)", "subscription_pauses",
+		"NOT (" + monthAligned("start_date") + ") OR NOT (" + monthAligned("end_date") + ")"},
+	{"pause_orphaned", "pause references a missing subscription", "subscription_pauses",
+		"NOT EXISTS (SELECT 1 FROM subscriptions s WHERE s.id = subscription_pauses.subscription_id)"},
+	{"price_change_negative_price", "scheduled price is negative", "price_changes",
+		"price < 0"},
+	{"price_change_unaligned_date", "effective_date is not the first day of a month (fix with normalize-dates)", "price_changes",
+		"NOT (" + monthAligned("effective_date") + ")"},
+	{"price_change_orphaned", "price change references a missing subscription", "price_changes",
+		"NOT EXISTS (SELECT 1 FROM subscriptions s WHERE s.id = price_changes.subscription_id)"},
+	{"api_key_orphaned_org", "API key references a missing organization", "api_keys",
+		"NOT EXISTS (SELECT 1 FROM organizations o WHERE o.id = api_keys.org_id)"},
+}
+
+// CheckResult результат одной проверки
+type CheckResult struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Table       string `json:"table"`
+	Count       int64  `json:"count"`
+	// Samples id первых нарушающих записей
+	Samples []string `json:"samples,omitempty"`
+}
+
+// Report результат проверки целостности
+type Report struct {
+	Checks []CheckResult `json:"checks"`
+	// Issues общее число нарушающих записей
+	Issues int64 `json:"issues"`
+}
+
+// OK сообщает, что нарушений нет
+func (r Report) OK() bool {
+	return r.Issues == 0
+}
+
+// Verify выполняет все проверки целостности; samples — сколько id нарушающих записей вернуть в каждой
+func Verify(ctx context.Context, db *gorm.DB, samples int) (Report, error) {
+	report := Report{Checks: make([]CheckResult, 0, len(checks))}
+	for _, c := range checks {
+		res := CheckResult{Name: c.name, Description: c.description, Table: c.table}
+		query := func() *gorm.DB { return db.WithContext(ctx).Table(c.table).Where(c.where) }
+		if err := query().Count(&res.Count).Error; err != nil {
+			return Report{}, fmt.Errorf("check %s: %w", c.name, err)
+		}
+		if res.Count > 0 && samples > 0 {
+			if err := query().Order("id").Limit(samples).Pluck("id::text", &res.Samples).Error; err != nil {
+				return Report{}, fmt.Errorf("check %s: %w", c.name, err)
+			}
+		}
+		report.Issues += res.Count
+		report.Checks = append(report.Checks, res)
+	}
+	return report, nil
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// scrapeTimeout ограничивает время запросов к БД при сборе бизнес-показателей
+const scrapeTimeout = 5 * time.Second
+
+// businessCollector вычисляет бизнес-показатели запросом к БД при каждом опросе
+type businessCollector struct {
+	db           *gorm.DB
+	active       *prometheus.Desc
+	monthlySpend *prometheus.Desc
+}
+
+func newBusinessCollector(db *gorm.DB) *businessCollector {
+	return &businessCollector{
+		db: db,
+		active: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active"),
+			"Subscriptions that have started and not ended as of the current month.", []string{"org"}, nil),
+		monthlySpend: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "monthly_recurring_spend"),
+			"Monthly recurring spend of active subscriptions in the organization currency, with non-monthly prices normalised per month.", []string{"org"}, nil),
+	}
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.monthlySpend
+}
+
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	// Цены организаций указаны в разных валютах, поэтому показатели считаются по каждой организации отдельно
+	var rows []struct {
+		OrgID  string
+		Active int64
+		Spend  float64
+	}
+	err := c.db.WithContext(ctx).Model(&model.Subscription{}).
+		Select(`org_id, COUNT(*) AS active, COALESCE(SUM(price::float / CASE billing_period
+			WHEN ? THEN 3 WHEN ? THEN 6 WHEN ? THEN 12 ELSE 1 END), 0) AS spend`,
+			model.BillingQuarterly, model.BillingSemiAnnual, model.BillingYearly).
+		Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", month, month).
+		Group("org_id").
+		Scan(&rows).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to collect business metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.active, err)
+		ch <- prometheus.NewInvalidMetric(c.monthlySpend, err)
+		return
+	}
+	for _, row := range rows {
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(row.Active), row.OrgID)
+		ch <- prometheus.MustNewConstMetric(c.monthlySpend, prometheus.GaugeValue, row.Spend, row.OrgID)
+	}
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// gormPlugin замеряет длительность запросов GORM через колбэки до и после каждой операции
+type gormPlugin struct {
+	m *Metrics
+}
+
+func (p *gormPlugin) Name() string {
+	return "metrics"
+}
+
+// registrar точка регистрации колбэка в цепочке GORM
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation     string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	for _, h := range hooks {
+		if err := h.before.Register("metrics:before_"+h.operation, p.before); err != nil {
+			return err
+		}
+		if err := h.after.Register("metrics:after_"+h.operation, p.after(h.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *gormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (p *gormPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		p.m.queryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
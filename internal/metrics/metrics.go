@@ -0,0 +1,110 @@
+// Package metrics публикует метрики Prometheus: HTTP, пул соединений, запросы GORM и бизнес-показатели.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+const namespace = "subscriptions"
+
+// Metrics набор метрик сервиса на собственном реестре
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+}
+
+// New создаёт метрики, регистрирует сборщики пула соединений и бизнес-показателей
+// и подключает к db плагин замера длительности запросов
+func New(db *gorm.DB, dbName string) (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "GORM query latency by operation and table.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(sqlDB, dbName),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		newBusinessCollector(db),
+	)
+	if err := db.Use(&gormPlugin{m: m}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		Registry: m.registry,
+		// Недоступность БД не должна скрывать остальные метрики
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap открывает исходный writer для http.ResponseController, например для Flush
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// Middleware считает запросы и их длительность. Метки берутся из шаблона маршрута mux,
+// а не из фактического пути, чтобы UUID в пути не раздували число временных рядов.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if tpl, err := cr.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		start := time.Now()
+		sr := &statusRecorder{w, http.StatusOK}
+		next.ServeHTTP(sr, r)
+
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(sr.status)).Inc()
+	})
+}
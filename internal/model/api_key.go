@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey ключ доступа для межсервисных вызовов.
+// Хранится только SHA-256 ключа, сам ключ показывается один раз при выпуске или ротации.
+type APIKey struct {
+	ID uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	// OrgID организация, от имени которой действует ключ
+	OrgID uuid.UUID `json:"org_id" gorm:"type:uuid;not null;index;default:'00000000-0000-0000-0000-000000000001'"`
+	Name  string    `json:"name" gorm:"not null" example:"billing-job"`
+	// Prefix открытая часть ключа, по ней ключ ищется при проверке
+	Prefix string   `json:"prefix" gorm:"not null;uniqueIndex" example:"osk_1a2b3c4d5e6f"`
+	Hash   string   `json:"-" gorm:"not null"`
+	Scopes []string `json:"scopes" gorm:"serializer:json;type:text;not null" example:"read,reports"`
+	// ExpiresAt nil означает бессрочный ключ
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
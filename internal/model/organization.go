@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOrgID организация, к которой относятся данные, созданные до появления организаций,
+// и запросы, для которых организация не указана
+var DefaultOrgID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// Organization организация (подразделение), данные которой изолированы от остальных
+type Organization struct {
+	ID   uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name string    `json:"name" gorm:"not null" example:"Marketing"`
+	// DefaultCurrency код валюты ISO 4217, в которой указаны цены подписок организации
+	DefaultCurrency string `json:"default_currency" gorm:"not null;default:'RUB'" example:"RUB"`
+	// FiscalYearStart номер месяца (1-12), с которого начинается финансовый год
+	FiscalYearStart int       `json:"fiscal_year_start" gorm:"not null;default:1" example:"1"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// FiscalYear возвращает первый и последний месяц финансового года year
+func (o Organization) FiscalYear(year int) (from, to time.Time) {
+	from = time.Date(year, time.Month(o.FiscalYearStart), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(1, -1, 0)
+}
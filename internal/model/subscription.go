@@ -5,11 +5,61 @@ import (
 	"time"
 )
 
+// BillingPeriod периодичность списаний по подписке
+type BillingPeriod string
+
+const (
+	BillingMonthly    BillingPeriod = "monthly"
+	BillingQuarterly  BillingPeriod = "quarterly"
+	BillingSemiAnnual BillingPeriod = "semiannual"
+	BillingYearly     BillingPeriod = "yearly"
+)
+
+// Months возвращает длительность периода в месяцах, 0 для неизвестного периода
+func (p BillingPeriod) Months() int {
+	switch p {
+	case BillingMonthly:
+		return 1
+	case BillingQuarterly:
+		return 3
+	case BillingSemiAnnual:
+		return 6
+	case BillingYearly:
+		return 12
+	}
+	return 0
+}
+
 type Subscription struct {
-	ID          uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	// OrgID организация, которой принадлежит подписка
+	OrgID       uuid.UUID  `json:"org_id" gorm:"type:uuid;not null;index;default:'00000000-0000-0000-0000-000000000001'"`
 	ServiceName string     `json:"service_name" gorm:"not null"`
 	Price       int        `json:"price" gorm:"not null"`
 	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
 	StartDate   time.Time  `json:"start_date" gorm:"not null"`
 	EndDate     *time.Time `json:"end_date,omitempty"`
+	// BillingPeriod цена Price списывается один раз за период
+	BillingPeriod BillingPeriod `json:"billing_period" gorm:"not null;default:'monthly'" example:"monthly"`
+	// TrialEndDate месяц первого платного списания, до него подписка бесплатна
+	TrialEndDate *time.Time          `json:"trial_end_date,omitempty"`
+	Pauses       []SubscriptionPause `json:"pauses,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	PriceChanges []PriceChange       `json:"price_changes,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// SubscriptionPause период приостановки подписки, списания в него не производятся.
+// EndDate == nil означает бессрочную паузу.
+type SubscriptionPause struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	SubscriptionID uuid.UUID  `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	StartDate      time.Time  `json:"start_date" gorm:"not null"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+}
+
+// PriceChange запланированное изменение цены подписки, действующее со списания в EffectiveDate и далее
+type PriceChange struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	SubscriptionID uuid.UUID `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	EffectiveDate  time.Time `json:"effective_date" gorm:"not null"`
+	Price          int       `json:"price" gorm:"not null"`
 }
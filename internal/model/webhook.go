@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий, на которые подписываются вебхуки
+const (
+	EventSubscriptionCreated         = "subscription.created"
+	EventSubscriptionUpdated         = "subscription.updated"
+	EventSubscriptionDeleted         = "subscription.deleted"
+	EventSubscriptionRenewalUpcoming = "subscription.renewal_upcoming"
+	EventSubscriptionEnded           = "subscription.ended"
+)
+
+// EventTypes все типы событий в порядке документации
+var EventTypes = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+	EventSubscriptionRenewalUpcoming,
+	EventSubscriptionEnded,
+}
+
+// WebhookEndpoint адрес, на который доставляются события организации
+type WebhookEndpoint struct {
+	ID uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	// OrgID организация, события которой получает адрес
+	OrgID uuid.UUID `json:"org_id" gorm:"type:uuid;not null;index;default:'00000000-0000-0000-0000-000000000001'"`
+	URL   string    `json:"url" gorm:"not null" example:"https://notify.example.com/hooks/subscriptions"`
+	// Secret ключ подписи HMAC-SHA256; показывается только при создании
+	Secret string `json:"-" gorm:"not null"`
+	// Events типы событий; пустой список недопустим
+	Events    []string  `json:"events" gorm:"serializer:json;type:text;not null" example:"subscription.created,subscription.deleted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribed сообщает, получает ли адрес события типа event
+func (e WebhookEndpoint) Subscribed(event string) bool {
+	return slices.Contains(e.Events, event)
+}
+
+// Состояния доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryFailed попытки исчерпаны, доставка в списке недоставленных до повторной отправки
+	DeliveryFailed = "failed"
+)
+
+// WebhookDelivery доставка одного события на один адрес. Запись создаётся в той же транзакции,
+// что и изменение подписки, поэтому событие не теряется при сбое между записью и отправкой.
+type WebhookDelivery struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrgID      uuid.UUID        `json:"org_id" gorm:"type:uuid;not null;index"`
+	EndpointID uuid.UUID        `json:"webhook_id" gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_dedup,priority:1"`
+	Endpoint   *WebhookEndpoint `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	// EventID общий для доставок одного события на разные адреса
+	EventID uuid.UUID `json:"event_id" gorm:"type:uuid;not null"`
+	Event   string    `json:"event" gorm:"not null" example:"subscription.created"`
+	// DedupKey не даёт отправить одно и то же плановое событие дважды
+	DedupKey string          `json:"-" gorm:"not null;uniqueIndex:idx_webhook_deliveries_dedup,priority:2"`
+	Payload  json.RawMessage `json:"payload" gorm:"serializer:json;type:jsonb;not null" swaggertype:"object"`
+	// Status pending, delivered или failed
+	Status   string `json:"status" gorm:"not null;index" example:"failed"`
+	Attempts int    `json:"attempts" example:"8"`
+	// NextAttemptAt время следующей попытки для доставок в состоянии pending
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"not null;index"`
+	LastStatusCode int        `json:"last_status_code,omitempty" example:"502"`
+	LastError      string     `json:"last_error,omitempty" example:"unexpected status 502"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,175 @@
+// Package ratelimit ограничивает частоту запросов клиентов по алгоритму token bucket.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+)
+
+// Классы эндпоинтов с отдельными лимитами
+const (
+	ClassDefault   = "default"
+	ClassWrite     = "write"
+	ClassExpensive = "expensive"
+	// ClassIP общий лимит адреса клиента на все запросы, проверяется до аутентификации
+	ClassIP = "ip"
+)
+
+// rule скорость пополнения корзины и её ёмкость
+type rule struct {
+	perMinute int
+	burst     int
+}
+
+// rate токенов в секунду
+func (r rule) rate() float64 {
+	return float64(r.perMinute) / 60
+}
+
+// bucket корзина токенов одного клиента в одном классе
+type bucket struct {
+	rule   rule
+	tokens float64
+	last   time.Time
+}
+
+// refill пополняет корзину за время, прошедшее с прошлого обращения
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.rule.burst), b.tokens+now.Sub(b.last).Seconds()*b.rule.rate())
+	b.last = now
+}
+
+// Result решение по запросу и данные для заголовков RateLimit-*
+type Result struct {
+	Allowed bool
+	// Limit ёмкость корзины
+	Limit int
+	// PerMinute скорость пополнения корзины
+	PerMinute int
+	// Remaining сколько запросов можно сделать сразу
+	Remaining int
+	// RetryAfter через сколько появится следующий токен; 0, если запрос разрешён
+	RetryAfter time.Duration
+	// Reset через сколько корзина заполнится полностью
+	Reset time.Duration
+}
+
+// Limiter хранит корзины клиентов в памяти процесса
+type Limiter struct {
+	rules map[string]rule
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// New создаёт ограничитель с лимитами из конфигурации
+func New(cfg config.RateLimitConfig) *Limiter {
+	return &Limiter{
+		rules: map[string]rule{
+			ClassDefault:   {cfg.Default.PerMinute, cfg.Default.Burst},
+			ClassWrite:     {cfg.Write.PerMinute, cfg.Write.Burst},
+			ClassExpensive: {cfg.Expensive.PerMinute, cfg.Expensive.Burst},
+			ClassIP:        {cfg.IP.PerMinute, cfg.IP.Burst},
+		},
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow списывает токен из корзины клиента key в классе class, если он есть
+func (l *Limiter) Allow(class, key string) Result {
+	r, ok := l.rules[class]
+	if !ok {
+		r = l.rules[ClassDefault]
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := class + "|" + key
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{rule: r, tokens: float64(r.burst), last: now}
+		l.buckets[id] = b
+	}
+	b.refill(now)
+
+	res := Result{Limit: r.burst, PerMinute: r.perMinute}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / r.rate())
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(r.burst) - b.tokens) / r.rate())
+	return res
+}
+
+// Middleware ограничивает запросы; classify относит запрос к классу эндпоинтов,
+// clientKey определяет клиента. Ответ содержит заголовки RateLimit-*, а при превышении — 429 и Retry-After.
+func (l *Limiter) Middleware(classify, clientKey func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := classify(r)
+			res := l.Allow(class, clientKey(r))
+			h := w.Header()
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=60;burst=%d", res.PerMinute, res.Limit))
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				i18n.WriteError(w, r, http.StatusTooManyRequests, "rate_limited")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SweepLoop периодически удаляет заполненные корзины: они неотличимы от новых, а без очистки
+// память росла бы с каждым новым клиентом
+func (l *Limiter) SweepLoop(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				l.sweep()
+			}
+		}
+	}
+}
+
+func (l *Limiter) sweep() {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.rule.burst) {
+			delete(l.buckets, id)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+)
+
+// testLimiter возвращает ограничитель с управляемыми часами
+func testLimiter() (*Limiter, *time.Time) {
+	l := New(config.RateLimitConfig{
+		Default:   config.RateLimitRule{PerMinute: 60, Burst: 3},
+		Write:     config.RateLimitRule{PerMinute: 6, Burst: 1},
+		Expensive: config.RateLimitRule{PerMinute: 30, Burst: 2},
+		IP:        config.RateLimitRule{PerMinute: 120, Burst: 5},
+	})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestAllow(t *testing.T) {
+	type step struct {
+		advance   time.Duration
+		class     string
+		key       string
+		allowed   bool
+		remaining int
+		retry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then refusal",
+			steps: []step{
+				{0, ClassDefault, "a", true, 2, 0},
+				{0, ClassDefault, "a", true, 1, 0},
+				{0, ClassDefault, "a", true, 0, 0},
+				{0, ClassDefault, "a", false, 0, time.Second},
+			},
+		},
+		{
+			name: "refill at the configured rate",
+			steps: []step{
+				{0, ClassWrite, "a", true, 0, 0},
+				{5 * time.Second, ClassWrite, "a", false, 0, 5 * time.Second},
+				{5 * time.Second, ClassWrite, "a", true, 0, 0},
+			},
+		},
+		{
+			name: "refill never exceeds burst",
+			steps: []step{
+				{0, ClassExpensive, "a", true, 1, 0},
+				{time.Hour, ClassExpensive, "a", true, 1, 0},
+			},
+		},
+		{
+			name: "clients have separate buckets",
+			steps: []step{
+				{0, ClassWrite, "a", true, 0, 0},
+				{0, ClassWrite, "b", true, 0, 0},
+				{0, ClassWrite, "a", false, 0, 10 * time.Second},
+			},
+		},
+		{
+			name: "classes have separate buckets",
+			steps: []step{
+				{0, ClassWrite, "a", true, 0, 0},
+				{0, ClassDefault, "a", true, 2, 0},
+				{0, ClassIP, "a", true, 4, 0},
+			},
+		},
+		{
+			name: "unknown class uses the default rule",
+			steps: []step{
+				{0, "other", "a", true, 2, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, now := testLimiter()
+			for i, s := range tt.steps {
+				*now = now.Add(s.advance)
+				res := l.Allow(s.class, s.key)
+				if res.Allowed != s.allowed || res.Remaining != s.remaining || res.RetryAfter.Round(time.Millisecond) != s.retry {
+					t.Errorf("step %d: Allow(%s, %s) = allowed %v, remaining %d, retry after %s; want %v, %d, %s",
+						i, s.class, s.key, res.Allowed, res.Remaining, res.RetryAfter, s.allowed, s.remaining, s.retry)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowReset(t *testing.T) {
+	l, _ := testLimiter()
+	l.Allow(ClassDefault, "a")
+	res := l.Allow(ClassDefault, "a")
+	// Два израсходованных токена восстанавливаются за две секунды при 60 в минуту
+	if res.Limit != 3 || res.PerMinute != 60 || res.Reset.Round(time.Millisecond) != 2*time.Second {
+		t.Errorf("Allow() = %+v, want limit 3, 60 per minute, reset in 2s", res)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, now := testLimiter()
+	l.Allow(ClassDefault, "idle")
+	l.Allow(ClassWrite, "busy")
+	*now = now.Add(2 * time.Second)
+	l.sweep()
+	if _, ok := l.buckets[ClassDefault+"|idle"]; ok {
+		t.Error("refilled bucket was not removed")
+	}
+	if _, ok := l.buckets[ClassWrite+"|busy"]; !ok {
+		t.Error("bucket that is still refilling was removed")
+	}
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	l, _ := testLimiter()
+	handler := l.Middleware(
+		func(*http.Request) string { return ClassWrite },
+		func(*http.Request) string { return "client" },
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		status int
+		header map[string]string
+	}{
+		{http.StatusNoContent, map[string]string{
+			"RateLimit-Policy":    "6;w=60;burst=1",
+			"RateLimit-Limit":     "1",
+			"RateLimit-Remaining": "0",
+			"RateLimit-Reset":     "10",
+			"Retry-After":         "",
+		}},
+		{http.StatusTooManyRequests, map[string]string{
+			"RateLimit-Limit":     "1",
+			"RateLimit-Remaining": "0",
+			"Retry-After":         "10",
+			"Content-Type":        "application/json",
+		}},
+	}
+	for i, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/subscriptions", nil))
+		if rec.Code != tt.status {
+			t.Errorf("request %d: status = %d, want %d", i+1, rec.Code, tt.status)
+		}
+		for name, want := range tt.header {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("request %d: %s = %q, want %q", i+1, name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/apperr"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Коды SQLSTATE PostgreSQL, которые переводятся в доменные ошибки
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgSerializationFail   = "40001"
+	pgDeadlockDetected    = "40P01"
+	pgLockNotAvailable    = "55P03"
+	pgQueryCanceled       = "57014"
+)
+
+// Translate переводит ошибку GORM или драйвера pgx в доменную ошибку apperr.
+// Уже доменные ошибки и nil возвращаются как есть. Исходная ошибка сохраняется в Err для журнала,
+// в коды ответа попадает только вид ошибки: имена ограничений и таблиц наружу не выдаются.
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	var domain *apperr.Error
+	if errors.As(err, &domain) {
+		return err
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperr.Wrap(apperr.KindNotFound, "not_found", err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return apperr.Wrap(apperr.KindConflict, "already_exists", err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return apperr.Wrap(apperr.KindConflict, "reference_conflict", err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return translatePgError(pgErr, err)
+	}
+	if unavailable(err) {
+		return apperr.Wrap(apperr.KindUnavailable, "service_unavailable", err)
+	}
+	return apperr.Wrap(apperr.KindInternal, "internal_error", err)
+}
+
+// translatePgError переводит ошибку, которую вернул сервер PostgreSQL, по её SQLSTATE
+func translatePgError(pgErr *pgconn.PgError, err error) error {
+	switch code := pgErr.Code; {
+	case code == pgUniqueViolation:
+		return apperr.Wrap(apperr.KindConflict, "already_exists", err)
+	case code == pgForeignKeyViolation:
+		return apperr.Wrap(apperr.KindConflict, "reference_conflict", err)
+	case code == pgNotNullViolation, code == pgCheckViolation,
+		// Класс 22 — data exception: слишком длинная строка, число вне диапазона, некорректный формат
+		strings.HasPrefix(code, "22"):
+		return apperr.Wrap(apperr.KindValidation, "invalid_data", err)
+	case code == pgSerializationFail, code == pgDeadlockDetected, code == pgLockNotAvailable, code == pgQueryCanceled,
+		// Классы 08 — ошибки соединения, 53 — нехватка ресурсов, 57P — остановка или перезапуск сервера
+		strings.HasPrefix(code, "08"), strings.HasPrefix(code, "53"), strings.HasPrefix(code, "57P"):
+		return apperr.Wrap(apperr.KindUnavailable, "service_unavailable", err)
+	}
+	return apperr.Wrap(apperr.KindInternal, "internal_error", err)
+}
+
+// unavailable распознаёт ошибки соединения с базой и истёкшие таймауты: повтор запроса может пройти
+func unavailable(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		pgconn.Timeout(err) ||
+		pgconn.SafeToRetry(err) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone)
+}
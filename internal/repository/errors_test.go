@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/apperr"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestTranslate(t *testing.T) {
+	pg := func(code string) error {
+		return fmt.Errorf("insert: %w", &pgconn.PgError{Code: code, ConstraintName: "subscriptions_pkey"})
+	}
+	tests := []struct {
+		name     string
+		err      error
+		wantKind apperr.Kind
+		wantCode string
+	}{
+		{"not found", gorm.ErrRecordNotFound, apperr.KindNotFound, "not_found"},
+		{"gorm duplicated key", gorm.ErrDuplicatedKey, apperr.KindConflict, "already_exists"},
+		{"gorm foreign key", gorm.ErrForeignKeyViolated, apperr.KindConflict, "reference_conflict"},
+		{"unique violation", pg(pgUniqueViolation), apperr.KindConflict, "already_exists"},
+		{"foreign key violation", pg(pgForeignKeyViolation), apperr.KindConflict, "reference_conflict"},
+		{"not null violation", pg(pgNotNullViolation), apperr.KindValidation, "invalid_data"},
+		{"check violation", pg(pgCheckViolation), apperr.KindValidation, "invalid_data"},
+		{"string too long", pg("22001"), apperr.KindValidation, "invalid_data"},
+		{"serialization failure", pg(pgSerializationFail), apperr.KindUnavailable, "service_unavailable"},
+		{"deadlock", pg(pgDeadlockDetected), apperr.KindUnavailable, "service_unavailable"},
+		{"query canceled", pg(pgQueryCanceled), apperr.KindUnavailable, "service_unavailable"},
+		{"connection failure", pg("08006"), apperr.KindUnavailable, "service_unavailable"},
+		{"too many connections", pg("53300"), apperr.KindUnavailable, "service_unavailable"},
+		{"admin shutdown", pg("57P01"), apperr.KindUnavailable, "service_unavailable"},
+		{"other server error", pg("42P01"), apperr.KindInternal, "internal_error"},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), apperr.KindUnavailable, "service_unavailable"},
+		{"bad connection", driver.ErrBadConn, apperr.KindUnavailable, "service_unavailable"},
+		{"plain error", errors.New("boom"), apperr.KindInternal, "internal_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e *apperr.Error
+			if !errors.As(Translate(tt.err), &e) {
+				t.Fatalf("Translate() = %v, want *apperr.Error", Translate(tt.err))
+			}
+			if e.Kind != tt.wantKind || e.Code != tt.wantCode {
+				t.Errorf("Translate() = %s/%s, want %s/%s", e.Kind, e.Code, tt.wantKind, tt.wantCode)
+			}
+			if !errors.Is(e, tt.err) {
+				t.Error("translated error does not keep the original")
+			}
+		})
+	}
+}
+
+func TestTranslateKeepsDomainErrors(t *testing.T) {
+	if Translate(nil) != nil {
+		t.Error("Translate(nil) != nil")
+	}
+	domain := apperr.New(apperr.KindNotFound, "subscription_not_found")
+	wrapped := fmt.Errorf("get: %w", domain)
+	if got := Translate(wrapped); got != wrapped {
+		t.Errorf("Translate() = %v, want the domain error unchanged", got)
+	}
+}
@@ -1,36 +1,90 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/logging"
 	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log"
+	"log/slog"
 )
 
-func InitRepository() (*gorm.DB, error) {
-	cfg, err := config.LoadConfig()
+// Models модели, таблицы которых создаются миграцией
+var Models = []any{&model.Organization{}, &model.Subscription{}, &model.SubscriptionPause{}, &model.PriceChange{}, &model.APIKey{},
+	&model.WebhookEndpoint{}, &model.WebhookDelivery{}}
+
+// InitRepository подключается к базе и применяет миграции
+func InitRepository(cfg *config.Config, logger *slog.Logger) (*gorm.DB, error) {
+	db, err := Open(cfg, logger)
 	if err != nil {
-		log.Fatalf("config load error: %v", err)
+		return nil, err
+	}
+	if err := Migrate(db, logger); err != nil {
+		return nil, err
 	}
+	logger.Info("database connected and migrated successfully")
+	return db, nil
+}
 
+// Open подключается к базе и настраивает пул соединений без миграций
+func Open(cfg *config.Config, logger *slog.Logger) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName,
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.DB.Host, cfg.DB.Port, cfg.DB.User, cfg.DB.Password, cfg.DB.Name, cfg.DB.SSLMode,
 	)
-	log.Printf("connecting with the parameters:\nHost: %s\nPort: %s\nUser: %s\nDB: %s\n",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBName)
+	logger.Info("connecting to database",
+		"host", cfg.DB.Host, "port", cfg.DB.Port, "user", cfg.DB.User, "db", cfg.DB.Name)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logging.NewGormLogger(logger)})
 	if err != nil {
-		log.Fatalf("db open error: %v", err)
+		return nil, fmt.Errorf("db open error: %w", err)
 	}
-	log.Println("db migrate ")
-	err = db.AutoMigrate(&model.Subscription{})
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("db migrate error: %v", err)
+		return nil, fmt.Errorf("db pool error: %w", err)
 	}
-	log.Println("Database connected and migrated successfully")
+	sqlDB.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
 	return db, nil
 }
+
+// Migrate создаёт и обновляет таблицы моделей и организацию по умолчанию. Повторный запуск безопасен.
+func Migrate(db *gorm.DB, logger *slog.Logger) error {
+	logger.Info("db migrate")
+	if err := db.AutoMigrate(Models...); err != nil {
+		return fmt.Errorf("db migrate error: %w", err)
+	}
+	defaultOrg := model.Organization{ID: model.DefaultOrgID, Name: "Default"}
+	if err := db.FirstOrCreate(&defaultOrg, "id = ?", model.DefaultOrgID).Error; err != nil {
+		return fmt.Errorf("db seed default organization error: %w", err)
+	}
+	return nil
+}
+
+// Ping проверяет доступность базы данных
+func Ping(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// CheckMigrations проверяет, что таблицы всех моделей созданы
+func CheckMigrations(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		migrator := db.WithContext(ctx).Migrator()
+		for _, m := range Models {
+			if !migrator.HasTable(m) {
+				return fmt.Errorf("table for %T is missing", m)
+			}
+		}
+		return nil
+	}
+}
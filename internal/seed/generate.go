@@ -0,0 +1,228 @@
+package seed
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// service сервис из каталога: месячная цена в рублях и доступные периоды оплаты
+type service struct {
+	name    string
+	monthly int
+	periods []model.BillingPeriod
+	// trial у сервиса бывает бесплатный первый месяц
+	trial bool
+}
+
+var (
+	monthlyOnly      = []model.BillingPeriod{model.BillingMonthly}
+	monthlyYearly    = []model.BillingPeriod{model.BillingMonthly, model.BillingYearly}
+	allPeriods       = []model.BillingPeriod{model.BillingMonthly, model.BillingQuarterly, model.BillingSemiAnnual, model.BillingYearly}
+	monthlyQuarterly = []model.BillingPeriod{model.BillingMonthly, model.BillingQuarterly}
+)
+
+// catalog популярные сервисы с ценами, близкими к реальным
+var catalog = []service{
+	{"Yandex Plus", 399, allPeriods, true},
+	{"Kinopoisk", 299, monthlyYearly, true},
+	{"Okko", 399, allPeriods, true},
+	{"ivi", 399, allPeriods, true},
+	{"Wink", 349, monthlyYearly, true},
+	{"Start", 299, monthlyYearly, true},
+	{"Premier", 299, monthlyQuarterly, true},
+	{"Netflix", 799, monthlyOnly, false},
+	{"Spotify", 299, monthlyYearly, true},
+	{"YouTube Premium", 299, monthlyOnly, true},
+	{"Apple Music", 169, monthlyYearly, true},
+	{"Apple iCloud+", 149, monthlyOnly, false},
+	{"VK Music", 249, monthlyOnly, true},
+	{"Zvuk", 199, monthlyYearly, true},
+	{"Litres", 399, monthlyYearly, true},
+	{"Bookmate", 349, monthlyYearly, true},
+	{"Yandex 360", 349, monthlyYearly, false},
+	{"Google One", 139, monthlyYearly, false},
+	{"Telegram Premium", 299, monthlyYearly, false},
+	{"ChatGPT Plus", 1990, monthlyOnly, false},
+	{"Microsoft 365", 499, monthlyYearly, false},
+	{"Adobe Creative Cloud", 2690, monthlyYearly, false},
+	{"Skyeng", 2490, monthlyQuarterly, false},
+	{"World Class", 6900, allPeriods, false},
+}
+
+// periodDiscount скидка за оплату на несколько месяцев вперёд
+var periodDiscount = map[model.BillingPeriod]float64{
+	model.BillingMonthly:    1,
+	model.BillingQuarterly:  0.95,
+	model.BillingSemiAnnual: 0.9,
+	model.BillingYearly:     0.8,
+}
+
+// Options параметры генератора
+type Options struct {
+	// Users число пользователей; у каждого от 1 до 6 подписок
+	Users int
+	// OrgID организация, в которой создаются подписки
+	OrgID uuid.UUID
+	// Seed начальное значение генератора: одинаковый Seed даёт одинаковые данные и пользователей
+	Seed uint64
+	// Now месяц, относительно которого выбираются даты; нулевое значение — текущий
+	Now time.Time
+}
+
+// generator источник случайных значений; пользователи получают UUID из того же потока,
+// поэтому повторный запуск с тем же Seed воспроизводит их
+type generator struct {
+	rnd *rand.Rand
+	now time.Time
+}
+
+func newGenerator(opts Options) *generator {
+	var seed [32]byte
+	binary.LittleEndian.PutUint64(seed[:], opts.Seed)
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return &generator{
+		rnd: rand.New(rand.NewChaCha8(seed)),
+		now: month(now.UTC().Year(), now.UTC().Month()),
+	}
+}
+
+// chance возвращает true с вероятностью p
+func (g *generator) chance(p float64) bool {
+	return g.rnd.Float64() < p
+}
+
+// between случайное целое из [lo, hi]
+func (g *generator) between(lo, hi int) int {
+	return lo + g.rnd.IntN(hi-lo+1)
+}
+
+func (g *generator) userID() uuid.UUID {
+	var id uuid.UUID
+	binary.LittleEndian.PutUint64(id[:8], g.rnd.Uint64())
+	binary.LittleEndian.PutUint64(id[8:], g.rnd.Uint64())
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return id
+}
+
+// period выбирает период оплаты: чаще всего помесячно, затем на год
+func (g *generator) period(s service) model.BillingPeriod {
+	if len(s.periods) == 1 {
+		return s.periods[0]
+	}
+	weights := map[model.BillingPeriod]int{
+		model.BillingMonthly: 70, model.BillingQuarterly: 10, model.BillingSemiAnnual: 5, model.BillingYearly: 15,
+	}
+	total := 0
+	for _, p := range s.periods {
+		total += weights[p]
+	}
+	n := g.rnd.IntN(total)
+	for _, p := range s.periods {
+		if n -= weights[p]; n < 0 {
+			return p
+		}
+	}
+	return s.periods[0]
+}
+
+// price цена за период с учётом скидки в виде «на рубль меньше круглой»: 399, 2389
+func price(monthly int, p model.BillingPeriod) int {
+	v := float64(monthly*p.Months()) * periodDiscount[p]
+	return int(math.Round(v/10))*10 - 1
+}
+
+// subscription создаёт подписку пользователя на сервис: начало в последние три года,
+// у части подписок есть пробный период, окончание и повышения цены
+func (g *generator) subscription(orgID, userID uuid.UUID, s service) model.Subscription {
+	sub := model.Subscription{
+		OrgID:         orgID,
+		UserID:        userID,
+		ServiceName:   s.name,
+		BillingPeriod: g.period(s),
+		StartDate:     g.now.AddDate(0, -g.between(0, 36), 0),
+	}
+	monthly := s.monthly
+	sub.Price = price(monthly, sub.BillingPeriod)
+	if s.trial && g.chance(0.3) {
+		sub.TrialEndDate = ptr(sub.StartDate.AddDate(0, 1, 0))
+	}
+	if g.chance(0.25) {
+		// Отменённые подписки и подписки, оплаченные до известной даты в будущем
+		end := sub.StartDate.AddDate(0, g.between(1, 24), 0)
+		if limit := g.now.AddDate(0, 12, 0); end.After(limit) {
+			end = limit
+		}
+		sub.EndDate = &end
+	}
+
+	// Повышения цены на 10-25% с шагом не меньше полугода, в том числе запланированные
+	last := sub.EndDate
+	if last == nil {
+		last = ptr(g.now.AddDate(0, 6, 0))
+	}
+	effective := sub.StartDate
+	for i := 0; i < 2 && g.chance(0.35); i++ {
+		effective = effective.AddDate(0, g.between(6, 18), 0)
+		if effective.After(*last) {
+			break
+		}
+		monthly = monthly * g.between(110, 125) / 100
+		sub.PriceChanges = append(sub.PriceChanges, model.PriceChange{
+			EffectiveDate: effective,
+			Price:         price(monthly, sub.BillingPeriod),
+		})
+	}
+	return sub
+}
+
+// Generate создаёт подписки для opts.Users пользователей, не сохраняя их
+func Generate(opts Options) []model.Subscription {
+	g := newGenerator(opts)
+	subs := make([]model.Subscription, 0, opts.Users*3)
+	for range opts.Users {
+		userID := g.userID()
+		for _, i := range g.rnd.Perm(len(catalog))[:g.between(1, 6)] {
+			subs = append(subs, g.subscription(opts.OrgID, userID, catalog[i]))
+		}
+	}
+	return subs
+}
+
+// batchSize число подписок в одном INSERT
+const batchSize = 500
+
+// Populate сохраняет подписки, созданные Generate, и возвращает их число.
+// Если подписки первого пользователя с этим Seed уже есть, считает данные созданными и ничего не меняет;
+// для новой порции данных нужен другой Seed.
+func Populate(ctx context.Context, db *gorm.DB, opts Options) (int, error) {
+	if opts.Users <= 0 {
+		return 0, nil
+	}
+	var existing int64
+	err := db.WithContext(ctx).Model(&model.Subscription{}).
+		Where("org_id = ? AND user_id = ?", opts.OrgID, newGenerator(opts).userID()).
+		Count(&existing).Error
+	if err != nil {
+		return 0, fmt.Errorf("check generated data: %w", err)
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+	subs := Generate(opts)
+	if err := db.WithContext(ctx).CreateInBatches(&subs, batchSize).Error; err != nil {
+		return 0, fmt.Errorf("create generated subscriptions: %w", err)
+	}
+	return len(subs), nil
+}
@@ -0,0 +1,158 @@
+package seed
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testOrg = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	now := time.Date(2025, 7, 15, 12, 0, 0, 0, time.UTC)
+	opts := Options{Users: 50, OrgID: testOrg, Seed: 42, Now: now}
+
+	first := Generate(opts)
+	if !reflect.DeepEqual(first, Generate(opts)) {
+		t.Error("the same options generated different data")
+	}
+	other := opts
+	other.Seed = 43
+	if reflect.DeepEqual(first, Generate(other)) {
+		t.Error("different seeds generated the same data")
+	}
+	// Populate узнаёт уже созданные данные по первому пользователю
+	if first[0].UserID != newGenerator(opts).userID() {
+		t.Error("the first generated user differs from the one Populate checks")
+	}
+	// Меньшее число пользователей даёт начало того же набора
+	prefix := opts
+	prefix.Users = 10
+	short := Generate(prefix)
+	if !reflect.DeepEqual(short, first[:len(short)]) {
+		t.Error("generating fewer users changed the data of the first users")
+	}
+}
+
+func TestGenerateInvariants(t *testing.T) {
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	services := map[string]service{}
+	for _, s := range catalog {
+		services[s.name] = s
+	}
+	subs := Generate(Options{Users: 500, OrgID: testOrg, Seed: 7, Now: now.Add(20 * 24 * time.Hour)})
+
+	perUser := map[uuid.UUID]map[string]bool{}
+	aligned := func(d time.Time) bool { return d.Equal(month(d.Year(), d.Month())) }
+	for i, sub := range subs {
+		s, ok := services[sub.ServiceName]
+		switch {
+		case !ok:
+			t.Fatalf("subscription %d: unknown service %q", i, sub.ServiceName)
+		case sub.OrgID != testOrg:
+			t.Errorf("subscription %d: org %s, want %s", i, sub.OrgID, testOrg)
+		case sub.UserID.Version() != 4 || sub.UserID.Variant() != uuid.RFC4122:
+			t.Errorf("subscription %d: user %s is not a random UUID", i, sub.UserID)
+		case !slices.Contains(s.periods, sub.BillingPeriod):
+			t.Errorf("subscription %d: %s is not billed %s", i, s.name, sub.BillingPeriod)
+		case sub.Price != price(s.monthly, sub.BillingPeriod):
+			t.Errorf("subscription %d: price %d, want %d", i, sub.Price, price(s.monthly, sub.BillingPeriod))
+		case !aligned(sub.StartDate) || sub.StartDate.After(now) || sub.StartDate.Before(now.AddDate(-3, 0, 0)):
+			t.Errorf("subscription %d: start %s is not a month of the last three years", i, sub.StartDate)
+		case sub.TrialEndDate != nil && (!s.trial || !sub.TrialEndDate.Equal(sub.StartDate.AddDate(0, 1, 0))):
+			t.Errorf("subscription %d: unexpected trial until %s", i, sub.TrialEndDate)
+		case sub.EndDate != nil && (!aligned(*sub.EndDate) || !sub.EndDate.After(sub.StartDate) || sub.EndDate.After(now.AddDate(1, 0, 0))):
+			t.Errorf("subscription %d: end %s for start %s", i, sub.EndDate, sub.StartDate)
+		}
+		if perUser[sub.UserID] == nil {
+			perUser[sub.UserID] = map[string]bool{}
+		}
+		if perUser[sub.UserID][sub.ServiceName] {
+			t.Errorf("subscription %d: user %s has %s twice", i, sub.UserID, sub.ServiceName)
+		}
+		perUser[sub.UserID][sub.ServiceName] = true
+
+		prev, prevPrice := sub.StartDate, sub.Price
+		for _, pc := range sub.PriceChanges {
+			if !aligned(pc.EffectiveDate) || pc.EffectiveDate.Before(prev.AddDate(0, 6, 0)) || pc.Price <= prevPrice {
+				t.Errorf("subscription %d: price change to %d at %s after %d at %s", i, pc.Price, pc.EffectiveDate, prevPrice, prev)
+			}
+			if sub.EndDate != nil && pc.EffectiveDate.After(*sub.EndDate) {
+				t.Errorf("subscription %d: price change at %s after end %s", i, pc.EffectiveDate, sub.EndDate)
+			}
+			prev, prevPrice = pc.EffectiveDate, pc.Price
+		}
+	}
+	if len(perUser) != 500 {
+		t.Errorf("generated %d users, want 500", len(perUser))
+	}
+	for user, names := range perUser {
+		if len(names) < 1 || len(names) > 6 {
+			t.Errorf("user %s has %d subscriptions, want 1-6", user, len(names))
+		}
+	}
+}
+
+func TestPrice(t *testing.T) {
+	tests := []struct {
+		monthly int
+		period  model.BillingPeriod
+		want    int
+	}{
+		{399, model.BillingMonthly, 399},
+		{399, model.BillingQuarterly, 1139},
+		{399, model.BillingSemiAnnual, 2149},
+		{399, model.BillingYearly, 3829},
+		{2690, model.BillingYearly, 25819},
+	}
+	for _, tt := range tests {
+		if got := price(tt.monthly, tt.period); got != tt.want {
+			t.Errorf("price(%d, %s) = %d, want %d", tt.monthly, tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestPopulateChecksFirstUser(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	err = db.Callback().Query().After("gorm:query").Register("test:record", func(db *gorm.DB) {
+		queries = append(queries, db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Users: 3, OrgID: testOrg, Seed: 9}
+	n, err := Populate(context.Background(), db, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(Generate(opts)) {
+		t.Errorf("Populate() = %d, want %d", n, len(Generate(opts)))
+	}
+	first := newGenerator(opts).userID().String()
+	if len(queries) != 1 || !strings.Contains(queries[0], "user_id = '"+first+"'") || !strings.Contains(queries[0], testOrg.String()) {
+		t.Errorf("existing data check = %q, want a count by org and user %s", queries, first)
+	}
+
+	if n, err := Populate(context.Background(), db, Options{OrgID: testOrg}); n != 0 || err != nil {
+		t.Errorf("Populate() without users = %d, %v; want 0, nil", n, err)
+	}
+}
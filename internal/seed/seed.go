@@ -0,0 +1,61 @@
+// Package seed заполняет базу демонстрационными данными.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Демонстрационные пользователи: постоянные UUID, чтобы повторный запуск не дублировал данные
+var (
+	DemoUserAlice = uuid.MustParse("d3e00000-0000-4000-8000-000000000001")
+	DemoUserBob   = uuid.MustParse("d3e00000-0000-4000-8000-000000000002")
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// demoSubscriptions набор подписок демонстрационных пользователей
+func demoSubscriptions(orgID uuid.UUID) []model.Subscription {
+	return []model.Subscription{
+		{OrgID: orgID, UserID: DemoUserAlice, ServiceName: "Yandex Plus", Price: 400, StartDate: month(2024, time.January), BillingPeriod: model.BillingMonthly},
+		{OrgID: orgID, UserID: DemoUserAlice, ServiceName: "Netflix", Price: 799, StartDate: month(2024, time.March), BillingPeriod: model.BillingMonthly,
+			PriceChanges: []model.PriceChange{{EffectiveDate: month(2025, time.January), Price: 899}}},
+		{OrgID: orgID, UserID: DemoUserAlice, ServiceName: "Spotify", Price: 1990, StartDate: month(2024, time.June), BillingPeriod: model.BillingYearly},
+		{OrgID: orgID, UserID: DemoUserBob, ServiceName: "Kinopoisk", Price: 299, StartDate: month(2024, time.February), EndDate: ptr(month(2024, time.December)), BillingPeriod: model.BillingMonthly},
+		{OrgID: orgID, UserID: DemoUserBob, ServiceName: "YouTube Premium", Price: 299, StartDate: month(2024, time.May), BillingPeriod: model.BillingMonthly,
+			TrialEndDate: ptr(month(2024, time.August))},
+		{OrgID: orgID, UserID: DemoUserBob, ServiceName: "Okko", Price: 1199, StartDate: month(2024, time.April), BillingPeriod: model.BillingQuarterly,
+			Pauses: []model.SubscriptionPause{{StartDate: month(2024, time.July), EndDate: ptr(month(2024, time.September))}}},
+	}
+}
+
+// Demo создаёт демонстрационные подписки в организации orgID и возвращает их число.
+// Если у демонстрационных пользователей уже есть подписки, ничего не меняет.
+func Demo(ctx context.Context, db *gorm.DB, orgID uuid.UUID) (int, error) {
+	var existing int64
+	err := db.WithContext(ctx).Model(&model.Subscription{}).
+		Where("org_id = ? AND user_id IN ?", orgID, []uuid.UUID{DemoUserAlice, DemoUserBob}).
+		Count(&existing).Error
+	if err != nil {
+		return 0, fmt.Errorf("check demo data: %w", err)
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+	subs := demoSubscriptions(orgID)
+	if err := db.WithContext(ctx).Create(&subs).Error; err != nil {
+		return 0, fmt.Errorf("create demo subscriptions: %w", err)
+	}
+	return len(subs), nil
+}
@@ -0,0 +1,194 @@
+// Package server управляет жизненным циклом HTTP-сервера и фоновых задач.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+)
+
+// Worker фоновая задача, работающая до отмены контекста
+type Worker func(ctx context.Context) error
+
+// Hook действие при остановке сервера, например закрытие пула соединений с БД
+type Hook func(ctx context.Context) error
+
+type namedWorker struct {
+	name string
+	run  Worker
+}
+
+type namedHook struct {
+	name string
+	run  Hook
+}
+
+// Server HTTP-сервер с таймаутами, плавной остановкой и фоновыми задачами
+type Server struct {
+	cfg  config.HTTPConfig
+	http *http.Server
+	// internal служебные серверы на отдельных адресах, например для /metrics
+	internal map[string]*http.Server
+	workers  []namedWorker
+	hooks    []namedHook
+	onDrain  []func()
+
+	mu        sync.Mutex
+	workerErr map[string]error
+}
+
+// New создаёт сервер для обработчика handler
+func New(cfg config.HTTPConfig, handler http.Handler) *Server {
+	return &Server{
+		cfg:      cfg,
+		http:     newHTTPServer(cfg, cfg.Addr, handler),
+		internal: make(map[string]*http.Server),
+	}
+}
+
+func newHTTPServer(cfg config.HTTPConfig, addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// AddInternal регистрирует служебный сервер на отдельном адресе с теми же таймаутами.
+// Он запускается вместе с основным и останавливается после него.
+func (s *Server) AddInternal(name, addr string, handler http.Handler) {
+	s.internal[name] = newHTTPServer(s.cfg, addr, handler)
+}
+
+// AddWorker регистрирует фоновую задачу. Задачи запускаются вместе с сервером
+// и получают отмену контекста после того, как HTTP-сервер перестал принимать запросы.
+func (s *Server) AddWorker(name string, w Worker) {
+	s.workers = append(s.workers, namedWorker{name, w})
+}
+
+// OnDrain регистрирует функцию, вызываемую сразу после сигнала остановки,
+// до того как сервер перестанет принимать соединения (например, для отказа проверки готовности)
+func (s *Server) OnDrain(fn func()) {
+	s.onDrain = append(s.onDrain, fn)
+}
+
+// CheckWorkers возвращает ошибку, если какая-либо фоновая задача завершилась аварийно
+func (s *Server) CheckWorkers(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for name, err := range s.workerErr {
+		errs = append(errs, fmt.Errorf("worker %s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// OnShutdown регистрирует действие при остановке. Действия выполняются в обратном порядке
+// после остановки HTTP-сервера и фоновых задач.
+func (s *Server) OnShutdown(name string, h Hook) {
+	s.hooks = append(s.hooks, namedHook{name, h})
+}
+
+// Run запускает сервер и блокируется до отмены ctx (например, по SIGTERM) или ошибки сервера,
+// после чего дожидается завершения текущих запросов в пределах ShutdownTimeout.
+func (s *Server) Run(ctx context.Context) error {
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var wg sync.WaitGroup
+	for _, w := range s.workers {
+		wg.Add(1)
+		go func(w namedWorker) {
+			defer wg.Done()
+			err := w.run(workerCtx)
+			if workerCtx.Err() != nil {
+				return
+			}
+			if err == nil {
+				err = errors.New("stopped unexpectedly")
+			}
+			slog.Error("worker stopped with error", "worker", w.name, "error", err)
+			s.mu.Lock()
+			if s.workerErr == nil {
+				s.workerErr = make(map[string]error)
+			}
+			s.workerErr[w.name] = err
+			s.mu.Unlock()
+		}(w)
+	}
+
+	serveErr := make(chan error, 1+len(s.internal))
+	go func() {
+		slog.Info("starting server", "addr", s.cfg.Addr)
+		if err := s.http.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+	for name, hs := range s.internal {
+		go func() {
+			slog.Info("starting internal server", "server", name, "addr", hs.Addr)
+			if err := hs.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("%s server: %w", name, err)
+			}
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutdown signal received, draining connections", "timeout", s.cfg.ShutdownTimeout)
+	case runErr = <-serveErr:
+	}
+
+	for _, fn := range s.onDrain {
+		fn()
+	}
+	// Даём балансировщику заметить отказ готовности, прежде чем закрыть приём соединений
+	if runErr == nil && s.cfg.ShutdownDelay > 0 {
+		time.Sleep(s.cfg.ShutdownDelay)
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(drainCtx); err != nil {
+		slog.Error("http server shutdown", "error", err)
+		s.http.Close()
+	}
+	for name, hs := range s.internal {
+		if err := hs.Shutdown(drainCtx); err != nil {
+			slog.Error("internal server shutdown", "server", name, "error", err)
+			hs.Close()
+		}
+	}
+
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-drainCtx.Done():
+		slog.Warn("background workers did not stop in time", "timeout", s.cfg.ShutdownTimeout)
+	}
+
+	for i := len(s.hooks) - 1; i >= 0; i-- {
+		h := s.hooks[i]
+		if err := h.run(drainCtx); err != nil {
+			slog.Error("shutdown hook failed", "hook", h.name, "error", err)
+		}
+	}
+	slog.Info("server stopped")
+	return runErr
+}
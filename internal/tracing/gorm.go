@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// rowsAffectedKey число затронутых строк
+var rowsAffectedKey = attribute.Key("db.rows_affected")
+
+// GormPlugin открывает клиентский спан на каждый запрос GORM с текстом SQL и числом затронутых строк.
+// Спан становится дочерним к спану из контекста запроса (db.WithContext).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+// registrar точка регистрации колбэка в цепочке GORM
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation     string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	for _, h := range hooks {
+		if err := h.before.Register("tracing:before_"+h.operation, p.before(h.operation)); err != nil {
+			return err
+		}
+		if err := h.after.Register("tracing:after_"+h.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (GormPlugin) before(operation string) func(*gorm.DB) {
+	tracer := otel.Tracer(instrumentationName)
+	return func(db *gorm.DB) {
+		name := "db." + operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		_, span := tracer.Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNamePostgreSQL,
+				semconv.DBOperationName(operation),
+				semconv.DBCollectionName(db.Statement.Table),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func (GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	// Текст запроса с плейсхолдерами, без значений параметров
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		rowsAffectedKey.Int64(db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
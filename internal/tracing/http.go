@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap открывает исходный writer для http.ResponseController, например для Flush
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// Middleware открывает серверный спан на каждый запрос, продолжая трассу из заголовков traceparent/tracestate.
+// Спан называется по методу и шаблону маршрута mux.
+func Middleware(next http.Handler) http.Handler {
+	tracer := otel.Tracer(instrumentationName)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := r.URL.Path
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if tpl, err := cr.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		sr := &statusRecorder{w, http.StatusOK}
+		next.ServeHTTP(sr, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(sr.status))
+		if sr.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sr.status))
+		}
+	})
+}
@@ -0,0 +1,175 @@
+// Package validation проверяет входные данные запросов и собирает все нарушения сразу,
+// чтобы клиент мог исправить запрос за один раз.
+package validation
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/google/uuid"
+)
+
+// MonthLayout формат месяца во входных данных API
+const MonthLayout = "01-2006"
+
+// Машиночитаемые коды нарушений. Коды стабильны: клиенты ветвятся по ним, а не по тексту.
+const (
+	CodeRequired      = "required"
+	CodeInvalidFormat = "invalid_format"
+	CodeTooLong       = "too_long"
+	CodeTooSmall      = "too_small"
+	CodeOutOfRange    = "out_of_range"
+	CodeNotAllowed    = "not_allowed"
+	CodeDateOrder     = "date_order"
+	CodeInPast        = "in_past"
+	CodeInvalidType   = "invalid_type"
+	CodeUnknownField  = "unknown_field"
+)
+
+// Violation нарушение правила для одного поля
+type Violation struct {
+	// Pointer JSON Pointer (RFC 6901) на поле в теле запроса
+	Pointer string `json:"pointer" example:"/price"`
+	Code    string `json:"code" example:"too_small"`
+	Detail  string `json:"detail" example:"must be greater than or equal to 0"`
+
+	// key и args сообщения в каталоге i18n, чтобы Detail можно было перевести на язык ответа
+	key  string
+	args []any
+}
+
+// Errors все нарушения, найденные во входных данных
+type Errors []Violation
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, v := range e {
+		parts[i] = v.Pointer + ": " + v.Detail
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Localize возвращает копию нарушений с Detail на языке lang
+func (e Errors) Localize(lang string) Errors {
+	out := make(Errors, len(e))
+	for i, v := range e {
+		v.Detail = i18n.T(lang, v.key, v.args...)
+		out[i] = v
+	}
+	return out
+}
+
+// Pointer собирает JSON Pointer из имён полей, экранируя "~" и "/"
+func Pointer(tokens ...string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		t = strings.ReplaceAll(t, "~", "~0")
+		b.WriteString(strings.ReplaceAll(t, "/", "~1"))
+	}
+	return b.String()
+}
+
+// Validator накапливает нарушения. Методы проверки возвращают разобранное значение,
+// чтобы проверку и преобразование входных данных не приходилось писать дважды.
+type Validator struct {
+	errs Errors
+}
+
+// Add добавляет нарушение с кодом code и сообщением key из каталога i18n.
+// Detail заполняется по-английски и переводится при ответе через Errors.Localize.
+func (v *Validator) Add(pointer, code, key string, args ...any) {
+	v.errs = append(v.errs, Violation{
+		Pointer: pointer,
+		Code:    code,
+		Detail:  i18n.T(i18n.English, key, args...),
+		key:     key,
+		args:    args,
+	})
+}
+
+// Check добавляет нарушение, если условие не выполнено, и возвращает условие
+func (v *Validator) Check(ok bool, pointer, code, key string, args ...any) bool {
+	if !ok {
+		v.Add(pointer, code, key, args...)
+	}
+	return ok
+}
+
+// Valid сообщает, что нарушений пока нет
+func (v *Validator) Valid() bool {
+	return len(v.errs) == 0
+}
+
+// Err возвращает Errors или nil, если нарушений нет
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return v.errs
+}
+
+// Required проверяет, что строка не пустая и не состоит из одних пробелов
+func (v *Validator) Required(pointer, value string) bool {
+	return v.Check(strings.TrimSpace(value) != "", pointer, CodeRequired, "validation.required")
+}
+
+// MaxLength ограничивает длину строки в символах
+func (v *Validator) MaxLength(pointer, value string, max int) bool {
+	return v.Check(utf8.RuneCountInString(value) <= max, pointer, CodeTooLong, "validation.too_long", max)
+}
+
+// Min проверяет нижнюю границу числа включительно
+func (v *Validator) Min(pointer string, value, min int) bool {
+	return v.Check(value >= min, pointer, CodeTooSmall, "validation.too_small", min)
+}
+
+// Range проверяет, что число лежит в отрезке [min, max]
+func (v *Validator) Range(pointer string, value, min, max int) bool {
+	return v.Check(value >= min && value <= max, pointer, CodeOutOfRange, "validation.out_of_range", min, max)
+}
+
+// UUID разбирает идентификатор; при ошибке возвращает uuid.Nil
+func (v *Validator) UUID(pointer, value string) uuid.UUID {
+	if !v.Required(pointer, value) {
+		return uuid.Nil
+	}
+	id, err := uuid.Parse(value)
+	if !v.Check(err == nil, pointer, CodeInvalidFormat, "validation.uuid") {
+		return uuid.Nil
+	}
+	return id
+}
+
+// Month разбирает обязательный месяц в формате MM-YYYY; при ошибке возвращает нулевое время
+func (v *Validator) Month(pointer, value string) time.Time {
+	if !v.Required(pointer, value) {
+		return time.Time{}
+	}
+	t, err := time.Parse(MonthLayout, value)
+	v.Check(err == nil, pointer, CodeInvalidFormat, "validation.month")
+	return t
+}
+
+// OptionalMonth разбирает необязательный месяц: nil и пустая строка дают nil
+func (v *Validator) OptionalMonth(pointer string, value *string) *time.Time {
+	if value == nil || *value == "" {
+		return nil
+	}
+	t, err := time.Parse(MonthLayout, *value)
+	if !v.Check(err == nil, pointer, CodeInvalidFormat, "validation.month") {
+		return nil
+	}
+	return &t
+}
+
+// NotBefore проверяет, что дата не раньше другой даты; нулевые значения не сравниваются,
+// так как ошибка их разбора уже учтена
+func (v *Validator) NotBefore(pointer string, t, from time.Time, fromField string) bool {
+	if t.IsZero() || from.IsZero() {
+		return true
+	}
+	return v.Check(!t.Before(from), pointer, CodeDateOrder, "validation.date_order", fromField)
+}
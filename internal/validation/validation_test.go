@@ -0,0 +1,132 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/i18n"
+	"github.com/google/uuid"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestPointer(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"price"}, "/price"},
+		{[]string{"pauses", "0", "end_date"}, "/pauses/0/end_date"},
+		{[]string{"a/b", "m~n"}, "/a~1b/m~0n"},
+	}
+	for _, tt := range tests {
+		if got := Pointer(tt.tokens...); got != tt.want {
+			t.Errorf("Pointer(%q) = %q, want %q", tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestValidatorRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		check    func(*Validator)
+		wantCode string
+	}{
+		{"required", func(v *Validator) { v.Required("/f", "ok") }, ""},
+		{"required empty", func(v *Validator) { v.Required("/f", "") }, CodeRequired},
+		{"required spaces", func(v *Validator) { v.Required("/f", " \t") }, CodeRequired},
+		{"max length in runes", func(v *Validator) { v.MaxLength("/f", "подписка", 8) }, ""},
+		{"too long", func(v *Validator) { v.MaxLength("/f", "подписка", 7) }, CodeTooLong},
+		{"min inclusive", func(v *Validator) { v.Min("/f", 0, 0) }, ""},
+		{"too small", func(v *Validator) { v.Min("/f", -1, 0) }, CodeTooSmall},
+		{"range bounds", func(v *Validator) { v.Range("/f", 12, 1, 12) }, ""},
+		{"out of range", func(v *Validator) { v.Range("/f", 13, 1, 12) }, CodeOutOfRange},
+		{"uuid", func(v *Validator) { v.UUID("/f", uuid.NewString()) }, ""},
+		{"uuid empty", func(v *Validator) { v.UUID("/f", "") }, CodeRequired},
+		{"uuid invalid", func(v *Validator) { v.UUID("/f", "not-a-uuid") }, CodeInvalidFormat},
+		{"month", func(v *Validator) { v.Month("/f", "07-2025") }, ""},
+		{"month empty", func(v *Validator) { v.Month("/f", "") }, CodeRequired},
+		{"month invalid", func(v *Validator) { v.Month("/f", "2025-07") }, CodeInvalidFormat},
+		{"optional month nil", func(v *Validator) { v.OptionalMonth("/f", nil) }, ""},
+		{"optional month empty", func(v *Validator) { v.OptionalMonth("/f", strPtr("")) }, ""},
+		{"optional month invalid", func(v *Validator) { v.OptionalMonth("/f", strPtr("13-2025")) }, CodeInvalidFormat},
+		{"date order", func(v *Validator) {
+			v.NotBefore("/f", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), "start_date")
+		}, CodeDateOrder},
+		{"same date", func(v *Validator) {
+			v.NotBefore("/f", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "start_date")
+		}, ""},
+		{"zero date is not compared", func(v *Validator) { v.NotBefore("/f", time.Time{}, time.Now(), "start_date") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validator
+			tt.check(&v)
+			err := v.Err()
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("unexpected violation: %v", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Code != tt.wantCode || errs[0].Pointer != "/f" {
+				t.Errorf("Err() = %v, want one %s violation at /f", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestParsedValues(t *testing.T) {
+	var v Validator
+	id := uuid.New()
+	if got := v.UUID("/id", id.String()); got != id {
+		t.Errorf("UUID() = %s, want %s", got, id)
+	}
+	if got := v.UUID("/bad", "x"); got != uuid.Nil {
+		t.Errorf("UUID() of an invalid value = %s, want uuid.Nil", got)
+	}
+	if got := v.Month("/m", "07-2025"); !got.Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Month() = %s, want 2025-07-01", got)
+	}
+	if got := v.OptionalMonth("/o", strPtr("bad")); got != nil {
+		t.Errorf("OptionalMonth() of an invalid value = %v, want nil", got)
+	}
+}
+
+func TestErrorsCollectsAllViolations(t *testing.T) {
+	var v Validator
+	v.Required("/service_name", "")
+	v.Min("/price", -5, 0)
+	v.Month("/start_date", "bad")
+
+	var errs Errors
+	if !errors.As(v.Err(), &errs) || len(errs) != 3 {
+		t.Fatalf("Err() = %v, want 3 violations", v.Err())
+	}
+	want := "validation failed: /service_name: is required; /price: must be greater than or equal to 0; /start_date: must be a month in MM-YYYY format"
+	if got := errs.Error(); got != want {
+		t.Errorf("Error() = %q\nwant %q", got, want)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	var v Validator
+	v.Min("/price", -5, 0)
+	errs := v.Err().(Errors)
+
+	ru := errs.Localize(i18n.Russian)
+	if ru[0].Detail != i18n.T(i18n.Russian, "validation.too_small", 0) {
+		t.Errorf("Localize(ru) detail = %q", ru[0].Detail)
+	}
+	if ru[0].Code != CodeTooSmall || ru[0].Pointer != "/price" {
+		t.Errorf("Localize(ru) changed code or pointer: %+v", ru[0])
+	}
+	if errs[0].Detail != i18n.T(i18n.English, "validation.too_small", 0) {
+		t.Errorf("Localize modified the original: %q", errs[0].Detail)
+	}
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/validation"
+)
+
+// errAddressNotAllowed доставка на внутренний адрес отклонена при соединении
+var errAddressNotAllowed = errors.New("address is not allowed")
+
+// reservedPrefixes диапазоны, не покрытые методами netip.Addr, но не ведущие в интернет:
+// через них адрес внутри сети сервиса можно выдать за внешний
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// PublicAddr сообщает, что адрес доступен из интернета: не loopback, не частный,
+// не link-local (в том числе 169.254.169.254), не unspecified и не multicast
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkURL проверяет, что адрес вебхука ведёт в интернет: IP-адрес в URL и все адреса,
+// в которые разрешается имя хоста, должны быть публичными. Нарушения возвращаются как validation.Errors.
+func (w *Webhooks) checkURL(ctx context.Context, rawURL string) error {
+	if w.cfg.AllowPrivate {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	var v validation.Validator
+	if addr, err := netip.ParseAddr(host); err == nil {
+		v.Check(PublicAddr(addr), "/url", validation.CodeNotAllowed, "validation.url_private")
+		return v.Err()
+	}
+	name := strings.ToLower(strings.TrimSuffix(host, "."))
+	if !v.Check(name != "localhost" && !strings.HasSuffix(name, ".localhost"), "/url", validation.CodeNotAllowed, "validation.url_private") {
+		return v.Err()
+	}
+	addrs, err := w.resolver.LookupNetIP(ctx, "ip", host)
+	if !v.Check(err == nil && len(addrs) > 0, "/url", validation.CodeInvalidFormat, "validation.url_unresolved") {
+		return v.Err()
+	}
+	for _, addr := range addrs {
+		if !v.Check(PublicAddr(addr), "/url", validation.CodeNotAllowed, "validation.url_private") {
+			break
+		}
+	}
+	return v.Err()
+}
+
+// newClient создаёт HTTP-клиент доставок. Перенаправления не выполняются, прокси из окружения
+// не используется, а адрес проверяется при каждом соединении: имя хоста, прошедшее проверку
+// при регистрации, может позже разрешиться во внутренний адрес.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if addr, err := netip.ParseAddr(host); err != nil || !PublicAddr(addr) {
+				return errAddressNotAllowed
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// Перенаправление считается неудачной попыткой: адрес должен принимать события сам
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
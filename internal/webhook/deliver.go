@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Заголовки запроса доставки
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-ID"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature "sha256=" и HMAC-SHA256 строки "<timestamp>.<тело>" в hex
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// userAgent представляется получателю
+const userAgent = "online-subscriptions-webhooks/1.0"
+
+// deliveryBatch сколько доставок отправляется одновременно
+const deliveryBatch = 20
+
+// maxDrainBody сколько байт ответа дочитывается, чтобы соединение можно было переиспользовать
+const maxDrainBody = 4 << 10
+
+// Sign возвращает значение HeaderSignature для тела body, отправленного в момент timestamp (Unix-время).
+// Получатель вычисляет его тем же способом и сравнивает за постоянное время.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliveryLoop отправляет ожидающие доставки до отмены ctx; подходит как фоновая задача сервера.
+// Несколько экземпляров сервиса делят очередь: каждая доставка берётся одним из них.
+func (w *Webhooks) DeliveryLoop() func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(w.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				// Полная пачка означает, что в очереди могут быть ещё доставки
+				for {
+					n, err := w.deliverDue(ctx)
+					if err != nil {
+						slog.ErrorContext(ctx, "failed to deliver webhooks", "error", err)
+					}
+					if err != nil || n < deliveryBatch || ctx.Err() != nil {
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// deliverDue отправляет пачку доставок, время которых подошло, и возвращает их число
+func (w *Webhooks) deliverDue(ctx context.Context) (int, error) {
+	deliveries, err := w.claim(ctx)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		wg.Add(1)
+		go func(d model.WebhookDelivery) {
+			defer wg.Done()
+			if err := w.attempt(ctx, d); err != nil {
+				slog.ErrorContext(ctx, "failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+			}
+		}(d)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// claim выбирает ожидающие доставки вместе с адресами и откладывает их следующую попытку на время
+// отправки, чтобы другие экземпляры их не взяли. Если экземпляр упадёт, доставка вернётся в очередь.
+func (w *Webhooks) claim(ctx context.Context) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	now := w.now()
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+			Order("next_attempt_at").Limit(deliveryBatch).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(2*w.cfg.Timeout)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	endpointIDs := make([]uuid.UUID, 0, len(deliveries))
+	for _, d := range deliveries {
+		endpointIDs = append(endpointIDs, d.EndpointID)
+	}
+	var endpoints []model.WebhookEndpoint
+	if err := w.db.WithContext(ctx).Where("id IN ?", endpointIDs).Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*model.WebhookEndpoint, len(endpoints))
+	for i := range endpoints {
+		byID[endpoints[i].ID] = &endpoints[i]
+	}
+	claimed := deliveries[:0]
+	for _, d := range deliveries {
+		// Адрес удалён после выборки; его доставки удалятся каскадно
+		if d.Endpoint = byID[d.EndpointID]; d.Endpoint != nil {
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+// attempt отправляет доставку и записывает результат: успех, повтор через backoff
+// или, если попытки исчерпаны, перенос в список недоставленных
+func (w *Webhooks) attempt(ctx context.Context, d model.WebhookDelivery) error {
+	status, sendErr := w.send(ctx, d)
+	now := w.now()
+	updates := map[string]any{
+		"attempts":         d.Attempts + 1,
+		"last_status_code": status,
+		"last_error":       "",
+	}
+	logger := slog.With("delivery_id", d.ID, "endpoint_id", d.EndpointID, "event", d.Event, "attempt", d.Attempts+1)
+	switch {
+	case sendErr == nil:
+		updates["status"] = model.DeliveryDelivered
+		updates["delivered_at"] = now
+		logger.DebugContext(ctx, "webhook delivered", "status", status)
+	case d.Attempts+1 >= w.cfg.MaxAttempts:
+		updates["status"] = model.DeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		logger.WarnContext(ctx, "webhook delivery failed, moved to dead letters", "error", sendErr)
+	default:
+		next := now.Add(w.backoff(d.Attempts + 1))
+		updates["next_attempt_at"] = next
+		updates["last_error"] = sendErr.Error()
+		logger.InfoContext(ctx, "webhook delivery failed, will retry", "error", sendErr, "next_attempt_at", next)
+	}
+	// Доставку могли поставить в очередь заново, пока шла попытка; тогда результат не записывается
+	return w.db.WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", d.ID, model.DeliveryPending, d.Attempts).
+		Updates(updates).Error
+}
+
+// send выполняет одну попытку и возвращает статус ответа; успехом считается любой 2xx
+func (w *Webhooks) send(ctx context.Context, d model.WebhookDelivery) (int, error) {
+	timestamp := w.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Endpoint.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderEventID, d.EventID.String())
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Endpoint.Secret, timestamp, d.Payload))
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Тело ответа не сохраняется: last_error видит администратор организации, и через него
+	// нельзя читать ответы чужих сервисов
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff задержка перед попыткой после attempt неудачных: BackoffBase·2^(attempt-1), не больше BackoffMax
+func (w *Webhooks) backoff(attempt int) time.Duration {
+	d := w.cfg.BackoffBase
+	for i := 1; i < attempt && d < w.cfg.BackoffMax; i++ {
+		d *= 2
+	}
+	return min(d, w.cfg.BackoffMax)
+}
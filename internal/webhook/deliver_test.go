@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/IlyaStarshinov/onlineSubscriptions/pkg/client"
+	"github.com/google/uuid"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{
+			name:      "known value",
+			secret:    "whsec_test",
+			timestamp: 1700000000,
+			body:      `{"id":"1"}`,
+			want:      "sha256=11bf4466ea17c3df3fd743af0b435368e16b7a05eb8eced85e8c4670767bdec5",
+		},
+		{
+			name:      "empty body",
+			secret:    "s",
+			timestamp: 1,
+			body:      "",
+			want:      "sha256=7845f55296e832a0ffeb7b3aa8d5dc4dc995a2d0de16b76379cff0f81f072e1a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	base := Sign("whsec_test", 1700000000, []byte(`{"id":"1"}`))
+	for name, got := range map[string]string{
+		"other secret":    Sign("whsec_other", 1700000000, []byte(`{"id":"1"}`)),
+		"other timestamp": Sign("whsec_test", 1700000001, []byte(`{"id":"1"}`)),
+		"other body":      Sign("whsec_test", 1700000000, []byte(`{"id":"2"}`)),
+	} {
+		if got == base {
+			t.Errorf("%s: signature did not change", name)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	w := &Webhooks{cfg: config.WebhooksConfig{BackoffBase: 30 * time.Second, BackoffMax: time.Hour}}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := w.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2a00:1450:4010:c0e::64", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := PublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURLRejectsInternalHosts(t *testing.T) {
+	w := New(nil, config.WebhooksConfig{Timeout: time.Second})
+	for _, url := range []string{
+		"http://127.0.0.1/hook",
+		"http://[::1]:8080/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/hook",
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+	} {
+		if err := w.checkURL(context.Background(), url); err == nil {
+			t.Errorf("checkURL(%s) accepted an internal address", url)
+		}
+	}
+	if err := w.checkURL(context.Background(), "https://8.8.8.8/hook"); err != nil {
+		t.Errorf("checkURL() rejected a public address: %v", err)
+	}
+
+	dev := New(nil, config.WebhooksConfig{Timeout: time.Second, AllowPrivate: true})
+	if err := dev.checkURL(context.Background(), "http://127.0.0.1/hook"); err != nil {
+		t.Errorf("checkURL() with AllowPrivate = %v", err)
+	}
+}
+
+func TestSendRefusesInternalAddressAtDial(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	defer srv.Close()
+
+	w := New(nil, config.WebhooksConfig{Timeout: time.Second})
+	_, err := w.send(context.Background(), delivery(srv.URL))
+	if err == nil || called {
+		t.Fatalf("send() to %s: err = %v, request reached server = %v", srv.URL, err, called)
+	}
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	const secret = "whsec_test"
+	var verifyErr error
+	var gotEvent string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = client.VerifyWebhookSignature(secret, r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp),
+			body, time.Now(), client.DefaultWebhookTolerance)
+		gotEvent = r.Header.Get(HeaderEvent)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w := New(nil, config.WebhooksConfig{Timeout: time.Second, AllowPrivate: true})
+	d := delivery(srv.URL)
+	d.Endpoint.Secret = secret
+	status, err := w.send(context.Background(), d)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("send() = %d, %v", status, err)
+	}
+	if verifyErr != nil {
+		t.Errorf("signature verification: %v", verifyErr)
+	}
+	if gotEvent != model.EventSubscriptionCreated {
+		t.Errorf("%s = %q, want %q", HeaderEvent, gotEvent, model.EventSubscriptionCreated)
+	}
+}
+
+func TestSendDoesNotKeepResponseBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+		io.WriteString(rw, "internal details")
+	}))
+	defer srv.Close()
+
+	w := New(nil, config.WebhooksConfig{Timeout: time.Second, AllowPrivate: true})
+	status, err := w.send(context.Background(), delivery(srv.URL))
+	if status != http.StatusBadGateway || err == nil {
+		t.Fatalf("send() = %d, %v", status, err)
+	}
+	if strings.Contains(err.Error(), "internal details") {
+		t.Errorf("error %q contains the response body", err)
+	}
+	if !strings.Contains(err.Error(), strconv.Itoa(http.StatusBadGateway)) {
+		t.Errorf("error %q does not mention the status", err)
+	}
+}
+
+func delivery(url string) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:       uuid.New(),
+		EventID:  uuid.New(),
+		Event:    model.EventSubscriptionCreated,
+		Payload:  []byte(`{"type":"subscription.created"}`),
+		Endpoint: &model.WebhookEndpoint{URL: url, Secret: "whsec_test"},
+	}
+}
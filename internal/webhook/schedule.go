@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/billing"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+)
+
+// endedLookback сколько после окончания подписки ещё отправляется subscription.ended;
+// покрывает перерыв в работе сервиса, пока он не длиннее этого срока
+const endedLookback = 7 * 24 * time.Hour
+
+// eventNamespace пространство имён UUID плановых событий: одно событие получает один id на всех адресах
+var eventNamespace = uuid.MustParse("5b0c3f6e-2f1d-4a8e-9c57-0e4b8d6a1f23")
+
+// ScheduleLoop до отмены ctx периодически записывает события, которые наступают со временем,
+// а не по запросу: subscription.renewal_upcoming и subscription.ended. Подходит как фоновая задача сервера.
+func (w *Webhooks) ScheduleLoop() func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(w.cfg.ScanInterval)
+		defer ticker.Stop()
+		for {
+			if err := w.scan(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to schedule webhook events", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// scan записывает плановые события организаций, у которых есть подписанные на них адреса.
+// Ключ события — подписка и дата, поэтому повторные проходы и другие экземпляры сервиса
+// не создают лишних доставок.
+func (w *Webhooks) scan(ctx context.Context) error {
+	var endpoints []model.WebhookEndpoint
+	err := w.db.WithContext(ctx).
+		Where("events LIKE ? OR events LIKE ?",
+			`%"`+model.EventSubscriptionRenewalUpcoming+`"%`, `%"`+model.EventSubscriptionEnded+`"%`).
+		Find(&endpoints).Error
+	if err != nil {
+		return fmt.Errorf("load webhooks: %w", err)
+	}
+	byOrg := make(map[uuid.UUID][]model.WebhookEndpoint)
+	for _, e := range endpoints {
+		byOrg[e.OrgID] = append(byOrg[e.OrgID], e)
+	}
+	now := w.now().UTC()
+	for orgID, endpoints := range byOrg {
+		if slices.ContainsFunc(endpoints, subscribed(model.EventSubscriptionRenewalUpcoming)) {
+			if err := w.scanRenewals(ctx, orgID, endpoints, now); err != nil {
+				return err
+			}
+		}
+		if slices.ContainsFunc(endpoints, subscribed(model.EventSubscriptionEnded)) {
+			if err := w.scanEnded(ctx, orgID, endpoints, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func subscribed(event string) func(model.WebhookEndpoint) bool {
+	return func(e model.WebhookEndpoint) bool { return e.Subscribed(event) }
+}
+
+// scanRenewals записывает subscription.renewal_upcoming для списаний в ближайшие RenewalNotice
+func (w *Webhooks) scanRenewals(ctx context.Context, orgID uuid.UUID, endpoints []model.WebhookEndpoint, now time.Time) error {
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := now.Add(w.cfg.RenewalNotice)
+	var subs []model.Subscription
+	err := w.db.WithContext(ctx).Preload("Pauses").Preload("PriceChanges").
+		Where("org_id = ? AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)", orgID, to, from).
+		Find(&subs).Error
+	if err != nil {
+		return fmt.Errorf("load subscriptions for renewals: %w", err)
+	}
+	db := w.db.WithContext(ctx)
+	for _, sub := range subs {
+		for _, charge := range billing.Charges(sub, from, to) {
+			key := fmt.Sprintf("%s:%s:%s", model.EventSubscriptionRenewalUpcoming, sub.ID, charge.Date.Format("2006-01-02"))
+			data := EventData{Subscription: sub, Charge: &charge}
+			err := w.enqueue(db, endpoints, model.EventSubscriptionRenewalUpcoming, scheduledEventID(key), key, now, data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanEnded записывает subscription.ended для подписок, последний оплаченный месяц которых закончился
+// не раньше endedLookback назад. Адреса, созданные позже окончания подписки, событие не получают.
+func (w *Webhooks) scanEnded(ctx context.Context, orgID uuid.UUID, endpoints []model.WebhookEndpoint, now time.Time) error {
+	lookback := max(endedLookback, 2*w.cfg.ScanInterval)
+	// end_date — последний месяц подписки, она заканчивается с началом следующего
+	var subs []model.Subscription
+	err := w.db.WithContext(ctx).
+		Where("org_id = ? AND end_date <= ? AND end_date > ?", orgID, now.AddDate(0, -1, 0), now.Add(-lookback).AddDate(0, -1, 0)).
+		Find(&subs).Error
+	if err != nil {
+		return fmt.Errorf("load ended subscriptions: %w", err)
+	}
+	db := w.db.WithContext(ctx)
+	for _, sub := range subs {
+		endedAt := sub.EndDate.AddDate(0, 1, 0)
+		key := fmt.Sprintf("%s:%s:%s", model.EventSubscriptionEnded, sub.ID, endedAt.Format("2006-01-02"))
+		err := w.enqueue(db, endpoints, model.EventSubscriptionEnded, scheduledEventID(key), key, endedAt, EventData{Subscription: sub})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scheduledEventID выводит id планового события из его ключа, чтобы все адреса получили один id
+func scheduledEventID(key string) uuid.UUID {
+	return uuid.NewSHA1(eventNamespace, []byte(key))
+}
@@ -0,0 +1,218 @@
+// Package webhook доставляет события подписок на адреса, зарегистрированные организациями.
+// События записываются в таблицу доставок в той же транзакции, что и изменение подписки,
+// а фоновые задачи отправляют их с подписью HMAC-SHA256 и повторами с экспоненциальной задержкой.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/apperr"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/billing"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/config"
+	"github.com/IlyaStarshinov/onlineSubscriptions/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// secretPrefix метка секретов подписи, как у API-ключей
+const secretPrefix = "whsec_"
+
+// secretLength длина случайной части секрета в байтах
+const secretLength = 24
+
+// Ошибки ненайденных записей; чужая запись неотличима от несуществующей
+var (
+	ErrEndpointNotFound = apperr.New(apperr.KindNotFound, "webhook_not_found")
+	ErrDeliveryNotFound = apperr.New(apperr.KindNotFound, "webhook_delivery_not_found")
+)
+
+// Event тело запроса, которое получает адрес
+type Event struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type" example:"subscription.created"`
+	CreatedAt time.Time `json:"created_at"`
+	OrgID     uuid.UUID `json:"org_id"`
+	Data      EventData `json:"data"`
+}
+
+// EventData подписка, к которой относится событие; Charge заполняется для subscription.renewal_upcoming
+type EventData struct {
+	Subscription model.Subscription `json:"subscription"`
+	Charge       *billing.Charge    `json:"charge,omitempty"`
+}
+
+// Webhooks регистрация адресов, запись событий и их доставка
+type Webhooks struct {
+	db       *gorm.DB
+	cfg      config.WebhooksConfig
+	client   *http.Client
+	resolver *net.Resolver
+	now      func() time.Time
+}
+
+// New создаёт сервис вебхуков поверх базы данных
+func New(db *gorm.DB, cfg config.WebhooksConfig) *Webhooks {
+	return &Webhooks{
+		db:       db,
+		cfg:      cfg,
+		client:   newClient(cfg.Timeout, cfg.AllowPrivate),
+		resolver: net.DefaultResolver,
+		now:      time.Now,
+	}
+}
+
+// CreateEndpoint регистрирует адрес организации orgID и возвращает его вместе с секретом подписи,
+// который больше нигде не показывается. Адреса внутри сети сервиса отклоняются.
+func (w *Webhooks) CreateEndpoint(ctx context.Context, orgID uuid.UUID, url string, events []string) (*model.WebhookEndpoint, error) {
+	if err := w.checkURL(ctx, url); err != nil {
+		return nil, err
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := &model.WebhookEndpoint{
+		OrgID:  orgID,
+		URL:    url,
+		Secret: secret,
+		Events: slices.Compact(slices.Sorted(slices.Values(events))),
+	}
+	if err := w.db.WithContext(ctx).Create(endpoint).Error; err != nil {
+		return nil, fmt.Errorf("failed to store webhook: %w", err)
+	}
+	return endpoint, nil
+}
+
+// ListEndpoints возвращает адреса организации без секретов
+func (w *Webhooks) ListEndpoints(ctx context.Context, orgID uuid.UUID) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	err := w.db.WithContext(ctx).Where("org_id = ?", orgID).Order("created_at").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// DeleteEndpoint удаляет адрес организации вместе с историей его доставок
+func (w *Webhooks) DeleteEndpoint(ctx context.Context, orgID, id uuid.UUID) error {
+	res := w.db.WithContext(ctx).Where("org_id = ? AND id = ?", orgID, id).Delete(&model.WebhookEndpoint{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrEndpointNotFound
+	}
+	return nil
+}
+
+// DeliveryFilter отбор доставок организации; пустые поля не ограничивают выборку
+type DeliveryFilter struct {
+	EndpointID uuid.UUID
+	Status     string
+}
+
+// Deliveries возвращает запрос к доставкам организации orgID, отобранным по filter
+func (w *Webhooks) Deliveries(ctx context.Context, orgID uuid.UUID, filter DeliveryFilter) *gorm.DB {
+	query := w.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("org_id = ?", orgID)
+	if filter.EndpointID != uuid.Nil {
+		query = query.Where("endpoint_id = ?", filter.EndpointID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	return query
+}
+
+// Redeliver ставит доставку организации в очередь заново с обнулённым счётчиком попыток,
+// в том числе уже доставленную или попавшую в список недоставленных
+func (w *Webhooks) Redeliver(ctx context.Context, orgID, id uuid.UUID) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("org_id = ? AND id = ?", orgID, id).First(&delivery).Error
+		if err != nil {
+			return err
+		}
+		delivery.Status = model.DeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = w.now()
+		delivery.LastStatusCode = 0
+		delivery.LastError = ""
+		delivery.DeliveredAt = nil
+		return tx.Save(&delivery).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Publish записывает событие event о подписке sub для всех адресов организации, подписанных на него.
+// tx — транзакция, в которой изменяется подписка: событие сохраняется, только если сохранено изменение.
+func (w *Webhooks) Publish(tx *gorm.DB, event string, sub model.Subscription) error {
+	var endpoints []model.WebhookEndpoint
+	if err := tx.Where("org_id = ?", sub.OrgID).Find(&endpoints).Error; err != nil {
+		return fmt.Errorf("load webhooks: %w", err)
+	}
+	eventID := uuid.New()
+	return w.enqueue(tx, endpoints, event, eventID, eventID.String(), w.now(), EventData{Subscription: sub})
+}
+
+// enqueue создаёт доставки события для тех адресов из endpoints, что подписаны на его тип и созданы
+// не позже occurredAt. Доставка с уже записанным ключом dedupKey для того же адреса пропускается.
+func (w *Webhooks) enqueue(tx *gorm.DB, endpoints []model.WebhookEndpoint, event string, eventID uuid.UUID, dedupKey string, occurredAt time.Time, data EventData) error {
+	now := w.now()
+	var deliveries []model.WebhookDelivery
+	var payload []byte
+	for _, e := range endpoints {
+		if !e.Subscribed(event) || e.CreatedAt.After(occurredAt) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(Event{ID: eventID, Type: event, CreatedAt: now.UTC(), OrgID: e.OrgID, Data: data})
+			if err != nil {
+				return fmt.Errorf("encode webhook event: %w", err)
+			}
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			OrgID:         e.OrgID,
+			EndpointID:    e.ID,
+			EventID:       eventID,
+			Event:         event,
+			DedupKey:      dedupKey,
+			Payload:       payload,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint_id"}, {Name: "dedup_key"}},
+		DoNothing: true,
+	}).Create(&deliveries).Error
+	if err != nil {
+		return fmt.Errorf("store webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, secretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
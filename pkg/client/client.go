@@ -0,0 +1,232 @@
+// Package client — Go-клиент API сервиса онлайн-подписок.
+//
+// Клиент оборачивает все эндпоинты API типизированными методами, принимает context.Context
+// в каждом вызове, повторяет запросы с экспоненциальной задержкой и учётом Retry-After
+// (POST — только при 429 и 503, см. RetryPolicy), постранично обходит списки
+// через итераторы и возвращает ошибки API как *Error с кодом сервера.
+//
+//	c, err := client.New(client.Config{BaseURL: "http://localhost:8080", Token: token})
+//	if err != nil {
+//		return err
+//	}
+//	for sub, err := range c.Subscriptions(ctx, client.ListOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(sub.ServiceName, sub.Price)
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Заголовки запросов и ответов API
+const (
+	orgHeader        = "X-Org-ID"
+	requestIDHeader  = "X-Request-ID"
+	nextCursorHeader = "X-Next-Cursor"
+)
+
+// defaultUserAgent значение User-Agent, если Config.UserAgent не задан
+const defaultUserAgent = "onlinesubscriptions-go-client"
+
+// Config параметры клиента. Обязателен только BaseURL; Token и APIKey взаимоисключающие.
+type Config struct {
+	// BaseURL адрес сервиса, например http://localhost:8080
+	BaseURL string
+	// Token JWT, передаётся как Authorization: Bearer <token>
+	Token string
+	// APIKey ключ межсервисного доступа, передаётся как Authorization: ApiKey <key>
+	APIKey string
+	// OrgID организация запросов (заголовок X-Org-ID); uuid.Nil — организация из токена или по умолчанию
+	OrgID uuid.UUID
+	// Language предпочитаемый язык сообщений об ошибках (Accept-Language), например ru или en
+	Language string
+	// UserAgent значение заголовка User-Agent
+	UserAgent string
+	// HTTPClient HTTP-клиент для запросов; nil — клиент с таймаутом 30 секунд
+	HTTPClient *http.Client
+	// Retry политика повторов; нулевое значение — DefaultRetryPolicy
+	Retry RetryPolicy
+}
+
+// Client клиент API. Безопасен для одновременного использования из нескольких горутин.
+type Client struct {
+	baseURL   *url.URL
+	auth      string
+	orgID     uuid.UUID
+	language  string
+	userAgent string
+	http      *http.Client
+	retry     RetryPolicy
+}
+
+// New создаёт клиент по конфигурации cfg
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", cfg.BaseURL)
+	}
+	if cfg.Token != "" && cfg.APIKey != "" {
+		return nil, errors.New("token and API key are mutually exclusive")
+	}
+	c := &Client{
+		baseURL:   base,
+		orgID:     cfg.OrgID,
+		language:  cfg.Language,
+		userAgent: cfg.UserAgent,
+		http:      cfg.HTTPClient,
+		retry:     cfg.Retry,
+	}
+	switch {
+	case cfg.Token != "":
+		c.auth = "Bearer " + cfg.Token
+	case cfg.APIKey != "":
+		c.auth = "ApiKey " + cfg.APIKey
+	}
+	if c.userAgent == "" {
+		c.userAgent = defaultUserAgent
+	}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: 30 * time.Second}
+	}
+	if c.retry.MaxAttempts == 0 {
+		c.retry = DefaultRetryPolicy
+	}
+	return c, nil
+}
+
+// request описание вызова API
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	// body тело запроса, кодируется в JSON; nil — без тела
+	body any
+	// accept медиа-тип ответа; пустой — application/json
+	accept string
+}
+
+// do выполняет запрос с повторами и разбирает успешный ответ в out.
+// out == nil — тело ответа отбрасывается, io.Writer — копируется как есть, иначе разбирается как JSON.
+func (c *Client) do(ctx context.Context, req request, out any) (http.Header, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("encode request body: %w", err)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, req, body)
+		if err != nil {
+			if ctx.Err() != nil || !c.retry.retryTransport(req.method) || attempt >= c.retry.MaxAttempts {
+				return nil, err
+			}
+			if err := sleep(ctx, c.retry.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode < 300 {
+			return resp.Header, readBody(resp, req.accept, out)
+		}
+		apiErr := readError(resp)
+		if !c.retry.retryStatus(req.method, resp.StatusCode) || attempt >= c.retry.MaxAttempts {
+			return nil, apiErr
+		}
+		delay := apiErr.RetryAfter
+		if delay <= 0 {
+			delay = c.retry.backoff(attempt)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send отправляет одну попытку запроса
+func (c *Client) send(ctx context.Context, req request, body []byte) (*http.Response, error) {
+	u := c.baseURL.JoinPath(req.path)
+	u.RawQuery = req.query.Encode()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	accept := req.accept
+	if accept == "" {
+		accept = "application/json"
+	}
+	httpReq.Header.Set("Accept", accept)
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != "" {
+		httpReq.Header.Set("Authorization", c.auth)
+	}
+	if c.orgID != uuid.Nil {
+		httpReq.Header.Set(orgHeader, c.orgID.String())
+	}
+	if c.language != "" {
+		httpReq.Header.Set("Accept-Language", c.language)
+	}
+	return c.http.Do(httpReq)
+}
+
+// readBody разбирает успешный ответ в out и закрывает тело. Если запрошен медиа-тип accept,
+// а сервер ответил другим (например, выгрузка отключена и пришёл JSON), тело не копируется.
+func readBody(resp *http.Response, accept string, out any) error {
+	defer resp.Body.Close()
+	if accept != "" && resp.StatusCode != http.StatusNoContent {
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != accept {
+			return fmt.Errorf("unexpected response content type %q, want %q", mediaType, accept)
+		}
+	}
+	switch dst := out.(type) {
+	case nil:
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	case io.Writer:
+		_, err := io.Copy(dst, resp.Body)
+		return err
+	default:
+		if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		return nil
+	}
+}
+
+// sleep ждёт delay или отмены контекста
+func sleep(ctx context.Context, delay time.Duration) error {
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,368 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fastRetry политика повторов без заметных задержек
+var fastRetry = RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+// errTransport ожидаемая ошибка соединения в TestRetries
+var errTransport = errors.New("transport error")
+
+// dropConnection статус в сценарии сервера: соединение закрывается без ответа
+const dropConnection = -1
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := New(Config{BaseURL: srv.URL, Token: "token", Retry: fastRetry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		statuses  []int
+		wantCalls int
+		// wantErr категория ошибки API; errTransport — ошибка соединения без ответа
+		wantErr error
+	}{
+		{"get succeeds", http.MethodGet, []int{200}, 1, nil},
+		{"get retried on 5xx", http.MethodGet, []int{500, 502, 200}, 3, nil},
+		{"get retried on 429", http.MethodGet, []int{429, 200}, 2, nil},
+		{"get retried after a dropped connection", http.MethodGet, []int{dropConnection, 200}, 2, nil},
+		{"get gives up after max attempts", http.MethodGet, []int{500, 500, 500, 500, 200}, 4, ErrUnavailable},
+		{"get not retried on 4xx", http.MethodGet, []int{404, 200}, 1, ErrNotFound},
+		{"put retried on 5xx", http.MethodPut, []int{500, 200}, 2, nil},
+		{"delete retried on 503", http.MethodDelete, []int{503, 204}, 2, nil},
+		{"post retried on 429", http.MethodPost, []int{429, 201}, 2, nil},
+		{"post retried on 503", http.MethodPost, []int{503, 201}, 2, nil},
+		{"post not retried on 500", http.MethodPost, []int{500, 201}, 1, ErrUnavailable},
+		{"post not retried on 502", http.MethodPost, []int{502, 201}, 1, ErrUnavailable},
+		{"post not retried after a dropped connection", http.MethodPost, []int{dropConnection, 201}, 1, errTransport},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1))
+				if r.Header.Get("Idempotency-Key") != "" {
+					t.Error("request carries an Idempotency-Key the server does not support")
+				}
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+				if status == dropConnection {
+					conn, _, _ := http.NewResponseController(w).Hijack()
+					conn.Close()
+					return
+				}
+				if status >= 400 {
+					w.Header().Set("Retry-After", "0")
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(status)
+					fmt.Fprintf(w, `{"code":"status_%d","error":"failed"}`, status)
+					return
+				}
+				w.WriteHeader(status)
+			}))
+			_, err := c.do(context.Background(), request{method: tt.method, path: "/subscriptions", body: map[string]int{"price": 1}}, nil)
+
+			if got := int(calls.Load()); got != tt.wantCalls {
+				t.Errorf("server got %d requests, want %d", got, tt.wantCalls)
+			}
+			switch {
+			case tt.wantErr == errTransport:
+				var apiErr *Error
+				if err == nil || errors.As(err, &apiErr) {
+					t.Errorf("error = %v, want a transport error", err)
+				}
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryHonorsContext(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/healthz"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("client waited for Retry-After after the context was done")
+	}
+}
+
+func TestSubscriptionsPagination(t *testing.T) {
+	ids := make([]uuid.UUID, 5)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	var pages []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("limit"); got != "2" {
+			t.Errorf("limit = %q, want 2", got)
+		}
+		after := r.URL.Query().Get("after")
+		pages = append(pages, after)
+		start := 0
+		if after != "" {
+			start, _ = strconv.Atoi(after)
+		}
+		end := min(start+2, len(ids))
+		if end < len(ids) {
+			w.Header().Set(nextCursorHeader, strconv.Itoa(end))
+		}
+		items := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			items = append(items, fmt.Sprintf(`{"id":%q,"service_name":"s","price":1}`, id))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+	}))
+
+	var got []uuid.UUID
+	for sub, err := range c.Subscriptions(context.Background(), ListOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, sub.ID)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(ids) {
+		t.Errorf("got %v, want %v", got, ids)
+	}
+	if strings.Join(pages, ",") != ",2,4" {
+		t.Errorf("requested cursors %q, want \"\", 2, 4", pages)
+	}
+
+	// Прерванный обход не запрашивает следующие страницы
+	pages = nil
+	for range c.Subscriptions(context.Background(), ListOptions{Limit: 2}) {
+		break
+	}
+	if len(pages) != 1 {
+		t.Errorf("early break requested %d pages, want 1", len(pages))
+	}
+}
+
+func TestSubscriptionsStopsOnError(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"invalid_cursor","error":"Invalid cursor"}`))
+			return
+		}
+		w.Header().Set(nextCursorHeader, "next")
+		w.Write([]byte(`[{"id":"` + uuid.NewString() + `"}]`))
+	}))
+	var items, errs int
+	for _, err := range c.Subscriptions(context.Background(), ListOptions{}) {
+		if err != nil {
+			errs++
+			if !HasCode(err, CodeInvalidCursor) {
+				t.Errorf("error = %v, want %s", err, CodeInvalidCursor)
+			}
+			continue
+		}
+		items++
+	}
+	if items != 1 || errs != 1 {
+		t.Errorf("got %d items and %d errors, want 1 and 1", items, errs)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		header      map[string]string
+		body        string
+		want        Error
+		wantIs      error
+		wantMessage string
+	}{
+		{
+			name:   "error response",
+			status: http.StatusNotFound,
+			header: map[string]string{requestIDHeader: "from-header"},
+			body:   `{"code":"subscription_not_found","error":"Subscription not found","request_id":"req-1"}`,
+			want:   Error{StatusCode: 404, Code: CodeSubscriptionNotFound, Message: "Subscription not found", RequestID: "req-1"},
+			wantIs: ErrNotFound,
+		},
+		{
+			name:   "problem details with violations",
+			status: http.StatusBadRequest,
+			body: `{"type":"urn:problem-type:onlinesubscriptions:validation","code":"validation_failed","title":"Request validation failed",
+				"detail":"1 invalid field(s)","errors":[{"pointer":"/price","code":"too_small","detail":"must be greater than or equal to 0"}]}`,
+			want: Error{StatusCode: 400, Code: CodeValidationFailed, Message: "Request validation failed: 1 invalid field(s)",
+				Violations: []Violation{{Pointer: "/price", Code: ViolationTooSmall, Detail: "must be greater than or equal to 0"}}},
+			wantIs: ErrInvalidRequest,
+		},
+		{
+			name:   "rate limited with retry after",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": "7"},
+			body:   `{"code":"rate_limited","error":"Rate limit exceeded, retry later"}`,
+			want:   Error{StatusCode: 429, Code: CodeRateLimited, Message: "Rate limit exceeded, retry later", RetryAfter: 7 * time.Second},
+			wantIs: ErrRateLimited,
+		},
+		{
+			name:   "not an API response",
+			status: http.StatusBadGateway,
+			header: map[string]string{requestIDHeader: "from-header"},
+			body:   "<html>bad gateway</html>",
+			want:   Error{StatusCode: 502, Message: "Bad Gateway", RequestID: "from-header"},
+			wantIs: ErrUnavailable,
+		},
+		{
+			name:   "conflict",
+			status: http.StatusConflict,
+			body:   `{"code":"already_exists","error":"Already exists"}`,
+			want:   Error{StatusCode: 409, Code: CodeAlreadyExists, Message: "Already exists"},
+			wantIs: ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			for k, v := range tt.header {
+				rec.Header().Set(k, v)
+			}
+			rec.WriteHeader(tt.status)
+			rec.WriteString(tt.body)
+
+			got := readError(rec.Result())
+			if fmt.Sprintf("%+v", *got) != fmt.Sprintf("%+v", tt.want) {
+				t.Errorf("readError() = %+v\nwant %+v", *got, tt.want)
+			}
+			if !errors.Is(got, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", got, tt.wantIs)
+			}
+			for _, other := range []error{ErrUnauthorized, ErrForbidden} {
+				if errors.Is(got, other) {
+					t.Errorf("errors.Is(%v, %v) = true", got, other)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"-3", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{4, 400 * time.Millisecond, 800 * time.Millisecond},
+		{5, 500 * time.Millisecond, time.Second},
+		{100, 500 * time.Millisecond, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if got := p.backoff(tt.attempt); got < tt.min || got > tt.max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"valid", Config{BaseURL: "http://localhost:8080/"}, ""},
+		{"missing scheme", Config{BaseURL: "localhost:8080"}, "scheme and host are required"},
+		{"unsupported scheme", Config{BaseURL: "ftp://example.com"}, "scheme and host are required"},
+		{"token and api key", Config{BaseURL: "https://api.example.com", Token: "t", APIKey: "k"}, "mutually exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("New() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	orgID := uuid.New()
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"` + uuid.NewString() + `"}`))
+	}))
+	defer srv.Close()
+	c, err := New(Config{BaseURL: srv.URL, APIKey: "key", OrgID: orgID, Language: "ru", UserAgent: "test-agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateSubscription(context.Background(), CreateSubscriptionRequest{ServiceName: "Netflix"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"Authorization":   "ApiKey key",
+		orgHeader:         orgID.String(),
+		"Accept-Language": "ru",
+		"User-Agent":      "test-agent",
+		"Content-Type":    "application/json",
+		"Accept":          "application/json",
+	}
+	for name, value := range want {
+		if got.Get(name) != value {
+			t.Errorf("%s = %q, want %q", name, got.Get(name), value)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Коды ошибок API. Не зависят от языка сообщения и совпадают с полем code ответа сервера.
+const (
+	CodeMissingCredentials      = "missing_credentials"
+	CodeUnsupportedAuthScheme   = "unsupported_auth_scheme"
+	CodeInvalidCredentials      = "invalid_credentials"
+	CodeVerifyCredentialsFailed = "verify_credentials_failed"
+	CodeActionForbidden         = "action_forbidden"
+	CodeForeignUserDenied       = "foreign_user_denied"
+	CodeForeignOrgDenied        = "foreign_org_denied"
+	CodePlatformAdminRequired   = "platform_admin_required"
+	CodeRateLimited             = "rate_limited"
+
+	CodeInvalidJSON          = "invalid_json"
+	CodeInvalidJSONAt        = "invalid_json_at"
+	CodeUnsupportedMedia     = "unsupported_media_type"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeEmptyBody            = "empty_body"
+	CodeTrailingData         = "trailing_data"
+	CodeInvalidRequest       = "invalid_request"
+	CodeValidationFailed     = "validation_failed"
+	CodeInvalidLimit         = "invalid_limit"
+	CodeInvalidCursor        = "invalid_cursor"
+	CodeInvalidCalendarToken = "invalid_calendar_token"
+	CodeCalendarDisabled     = "calendar_disabled"
+
+	CodeSubscriptionNotFound = "subscription_not_found"
+	CodePriceChangeNotFound  = "price_change_not_found"
+	CodePauseNotFound        = "pause_not_found"
+	CodeOrganizationNotFound = "organization_not_found"
+	CodeAPIKeyNotFound       = "api_key_not_found"
+	CodeWebhookNotFound      = "webhook_not_found"
+	CodeDeliveryNotFound     = "webhook_delivery_not_found"
+	CodeNotFound             = "not_found"
+
+	CodeAlreadyExists      = "already_exists"
+	CodeReferenceConflict  = "reference_conflict"
+	CodeInvalidData        = "invalid_data"
+	CodeServiceUnavailable = "service_unavailable"
+	CodeInternalError      = "internal_error"
+)
+
+// Коды нарушений в Violation.Code
+const (
+	ViolationRequired      = "required"
+	ViolationInvalidFormat = "invalid_format"
+	ViolationTooLong       = "too_long"
+	ViolationTooSmall      = "too_small"
+	ViolationOutOfRange    = "out_of_range"
+	ViolationNotAllowed    = "not_allowed"
+	ViolationDateOrder     = "date_order"
+	ViolationInPast        = "in_past"
+	ViolationInvalidType   = "invalid_type"
+	ViolationUnknownField  = "unknown_field"
+)
+
+// Категории ошибок API для errors.Is; категория определяется HTTP-статусом ответа
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrForbidden      = errors.New("forbidden")
+	ErrNotFound       = errors.New("not found")
+	ErrConflict       = errors.New("conflict")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrUnavailable    = errors.New("service unavailable")
+)
+
+// statusErrors категории ошибок по HTTP-статусу
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrInvalidRequest,
+	http.StatusRequestEntityTooLarge: ErrInvalidRequest,
+	http.StatusUnsupportedMediaType:  ErrInvalidRequest,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusConflict:              ErrConflict,
+	http.StatusTooManyRequests:       ErrRateLimited,
+	http.StatusServiceUnavailable:    ErrUnavailable,
+}
+
+// Violation нарушение в поле тела запроса
+type Violation struct {
+	// Pointer JSON Pointer (RFC 6901) на поле, например /price
+	Pointer string `json:"pointer"`
+	Code    string `json:"code"`
+	Detail  string `json:"detail"`
+}
+
+// Error ошибка, которую вернул API
+type Error struct {
+	StatusCode int
+	// Code машиночитаемый код ошибки, одна из констант Code*; пустой, если ответ не от API
+	Code string
+	// Message сообщение на языке из Config.Language
+	Message   string
+	RequestID string
+	// RetryAfter задержка из заголовка Retry-After
+	RetryAfter time.Duration
+	// Violations нарушения по полям для CodeValidationFailed
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is позволяет проверять категорию ошибки: errors.Is(err, client.ErrNotFound)
+func (e *Error) Is(target error) bool {
+	if target == ErrUnavailable && e.StatusCode >= 500 {
+		return true
+	}
+	return statusErrors[e.StatusCode] == target
+}
+
+// HasCode сообщает, что err — ошибка API с кодом code
+func HasCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// errorBody объединение форматов ErrorResponse и application/problem+json
+type errorBody struct {
+	Code      string      `json:"code"`
+	Error     string      `json:"error"`
+	Title     string      `json:"title"`
+	Detail    string      `json:"detail"`
+	RequestID string      `json:"request_id"`
+	Errors    []Violation `json:"errors"`
+}
+
+// maxErrorBody наибольший размер тела ответа с ошибкой, который читает клиент
+const maxErrorBody = 1 << 20
+
+// readError разбирает ответ с ошибкой и закрывает тело
+func readError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(requestIDHeader),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	var body errorBody
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if json.Unmarshal(data, &body) != nil || body.Code == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+		return e
+	}
+	e.Code = body.Code
+	e.Message = body.Error
+	if e.Message == "" {
+		e.Message = body.Title
+		if body.Detail != "" {
+			e.Message += ": " + body.Detail
+		}
+	}
+	if body.RequestID != "" {
+		e.RequestID = body.RequestID
+	}
+	e.Violations = body.Errors
+	return e
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Organization возвращает организацию запросов клиента
+func (c *Client) Organization(ctx context.Context) (*Organization, error) {
+	var out Organization
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/organization"}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateOrganization изменяет настройки организации запросов клиента
+func (c *Client) UpdateOrganization(ctx context.Context, in OrganizationRequest) (*Organization, error) {
+	var out Organization
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: "/organization", body: in}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateOrganization создаёт организацию; доступно администратору платформы
+func (c *Client) CreateOrganization(ctx context.Context, in OrganizationRequest) (*Organization, error) {
+	var out Organization
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/organizations", body: in}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListOrganizations возвращает все организации; доступно администратору платформы
+func (c *Client) ListOrganizations(ctx context.Context) ([]Organization, error) {
+	var out []Organization
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/organizations"}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateAPIKey выпускает API-ключ; секрет есть только в ответе
+func (c *Client) CreateAPIKey(ctx context.Context, in CreateAPIKeyRequest) (*APIKeyWithSecret, error) {
+	var out APIKeyWithSecret
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/api-keys", body: in}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAPIKeys возвращает ключи организации без секретов
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var out []APIKey
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/api-keys"}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RotateAPIKey выпускает новый секрет ключа id, старый перестаёт действовать
+func (c *Client) RotateAPIKey(ctx context.Context, id uuid.UUID) (*APIKeyWithSecret, error) {
+	var out APIKeyWithSecret
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/api-keys/" + id.String() + "/rotate"}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAPIKey отзывает ключ id
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/api-keys/" + id.String()}, nil)
+	return err
+}
+
+// Health проверяет, что сервис запущен (/healthz)
+func (c *Client) Health(ctx context.Context) error {
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/healthz"}, nil)
+	return err
+}
+
+// Readiness возвращает отчёт /readyz. Неготовый сервис отвечает 503 с тем же отчётом,
+// поэтому запрос не повторяется, а отчёт возвращается без ошибки.
+func (c *Client) Readiness(ctx context.Context) (*Readiness, error) {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/readyz"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, readError(resp)
+	}
+	var out Readiness
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
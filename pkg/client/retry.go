@@ -0,0 +1,71 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy политика повторов запросов.
+//
+// GET, PUT и DELETE идемпотентны и повторяются при 429, любом 5xx и сетевой ошибке.
+// POST повторяется только при 429 и 503: в этих случаях сервер отклоняет запрос, ничего не записав.
+// После сетевой ошибки или другого 5xx POST не повторяется: запрос мог быть выполнен, а у API
+// нет ключей идемпотентности, по которым сервер отбросил бы повтор, поэтому запись создалась бы дважды.
+type RetryPolicy struct {
+	// MaxAttempts наибольшее число попыток, включая первую; 1 отключает повторы
+	MaxAttempts int
+	// BaseDelay задержка перед первым повтором, дальше она удваивается
+	BaseDelay time.Duration
+	// MaxDelay наибольшая задержка между попытками, если сервер не указал Retry-After
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy политика повторов по умолчанию: до 4 попыток с задержкой от 200 мс до 5 с
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// backoff возвращает задержку перед повтором после попытки attempt:
+// BaseDelay·2^(attempt-1), не больше MaxDelay, со случайным разбросом в пределах половины
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << min(attempt-1, 30)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryStatus сообщает, можно ли повторить запрос method, получивший ответ status
+func (p RetryPolicy) retryStatus(method string, status int) bool {
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		return true
+	}
+	return idempotent(method) && status >= 500
+}
+
+// retryTransport сообщает, можно ли повторить запрос method после сетевой ошибки:
+// неизвестно, дошёл ли он до сервера, поэтому повторяются только идемпотентные запросы
+func (p RetryPolicy) retryTransport(method string) bool {
+	return idempotent(method)
+}
+
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP-даты
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// CreatePause приостанавливает подписку subID на период
+func (c *Client) CreatePause(ctx context.Context, subID uuid.UUID, in CreatePauseRequest) (*Pause, error) {
+	var out Pause
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: pausesPath(subID), body: in}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPauses возвращает паузы подписки subID
+func (c *Client) ListPauses(ctx context.Context, subID uuid.UUID) ([]Pause, error) {
+	var out []Pause
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: pausesPath(subID)}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeletePause удаляет паузу pauseID подписки subID
+func (c *Client) DeletePause(ctx context.Context, subID, pauseID uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: pausesPath(subID) + "/" + pauseID.String()}, nil)
+	return err
+}
+
+func pausesPath(subID uuid.UUID) string {
+	return "/subscriptions/" + subID.String() + "/pauses"
+}
+
+// CreatePriceChange планирует изменение цены подписки subID
+func (c *Client) CreatePriceChange(ctx context.Context, subID uuid.UUID, in CreatePriceChangeRequest) (*PriceChange, error) {
+	var out PriceChange
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: priceChangesPath(subID), body: in}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPriceChanges возвращает запланированные изменения цены подписки subID
+func (c *Client) ListPriceChanges(ctx context.Context, subID uuid.UUID) ([]PriceChange, error) {
+	var out []PriceChange
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: priceChangesPath(subID)}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeletePriceChange удаляет изменение цены changeID подписки subID
+func (c *Client) DeletePriceChange(ctx context.Context, subID, changeID uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: priceChangesPath(subID) + "/" + changeID.String()}, nil)
+	return err
+}
+
+func priceChangesPath(subID uuid.UUID) string {
+	return "/subscriptions/" + subID.String() + "/price-changes"
+}
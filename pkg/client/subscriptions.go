@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// Форматы выгрузки списка подписок для ExportSubscriptions
+const (
+	FormatCSV    = "text/csv"
+	FormatXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	FormatNDJSON = "application/x-ndjson"
+)
+
+// DefaultPageSize размер страницы итератора Subscriptions, если ListOptions.Limit не задан
+const DefaultPageSize = 100
+
+// ListOptions параметры списка подписок
+type ListOptions struct {
+	// UserID ограничивает список подписками пользователя; uuid.Nil — все доступные подписки
+	UserID uuid.UUID
+	// Limit размер страницы (1-1000); 0 в ListSubscriptions — весь список одним ответом
+	Limit int
+	// After курсор: NextCursor предыдущей страницы
+	After string
+}
+
+// Page страница списка подписок
+type Page struct {
+	Items []Subscription
+	// NextCursor курсор следующей страницы; пустой на последней странице
+	NextCursor string
+}
+
+// CreateSubscription создаёт подписку
+func (c *Client) CreateSubscription(ctx context.Context, in CreateSubscriptionRequest) (*Subscription, error) {
+	var sub Subscription
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/subscriptions", body: in}, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// UpdateSubscription частично изменяет подписку id
+func (c *Client) UpdateSubscription(ctx context.Context, id uuid.UUID, in UpdateSubscriptionRequest) (*Subscription, error) {
+	var sub Subscription
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: "/subscriptions/" + id.String(), body: in}, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// DeleteSubscription удаляет подписку id
+func (c *Client) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/subscriptions/" + id.String()}, nil)
+	return err
+}
+
+// ListSubscriptions возвращает одну страницу подписок
+func (c *Client) ListSubscriptions(ctx context.Context, opts ListOptions) (*Page, error) {
+	req := request{method: http.MethodGet, path: subscriptionsPath(opts.UserID), query: url.Values{}}
+	if opts.Limit > 0 {
+		req.query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.After != "" {
+		req.query.Set("after", opts.After)
+	}
+	page := &Page{}
+	header, err := c.do(ctx, req, &page.Items)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = header.Get(nextCursorHeader)
+	return page, nil
+}
+
+// Subscriptions обходит все подписки, запрашивая их страницами по opts.Limit (по умолчанию DefaultPageSize).
+// После первой ошибки итератор выдаёт её и останавливается.
+func (c *Client) Subscriptions(ctx context.Context, opts ListOptions) iter.Seq2[Subscription, error] {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultPageSize
+	}
+	return func(yield func(Subscription, error) bool) {
+		for {
+			page, err := c.ListSubscriptions(ctx, opts)
+			if err != nil {
+				yield(Subscription{}, err)
+				return
+			}
+			for _, sub := range page.Items {
+				if !yield(sub, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			opts.After = page.NextCursor
+		}
+	}
+}
+
+// ExportSubscriptions записывает в w выгрузку подписок в формате format (FormatCSV, FormatXLSX, FormatNDJSON).
+// userID == uuid.Nil — все доступные подписки.
+func (c *Client) ExportSubscriptions(ctx context.Context, userID uuid.UUID, format string, w io.Writer) error {
+	_, err := c.do(ctx, request{method: http.MethodGet, path: subscriptionsPath(userID), accept: format}, w)
+	return err
+}
+
+func subscriptionsPath(userID uuid.UUID) string {
+	if userID == uuid.Nil {
+		return "/subscriptions"
+	}
+	return "/subscriptions/" + userID.String()
+}
+
+// Summary возвращает сумму подписок за период
+func (c *Client) Summary(ctx context.Context, p SummaryParams) (*Summary, error) {
+	q := url.Values{}
+	setUUID(q, "user_id", p.UserID)
+	setString(q, "service_name", p.ServiceName)
+	setString(q, "start_date", p.StartDate)
+	setString(q, "end_date", p.EndDate)
+	if p.FiscalYear != 0 {
+		q.Set("fiscal_year", strconv.Itoa(p.FiscalYear))
+	}
+	var out Summary
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/subscriptions/summary", query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Forecast возвращает помесячный прогноз расходов
+func (c *Client) Forecast(ctx context.Context, p ForecastParams) (*Forecast, error) {
+	q := url.Values{}
+	if p.Months != 0 {
+		q.Set("months", strconv.Itoa(p.Months))
+	}
+	setUUID(q, "user_id", p.UserID)
+	setString(q, "service_name", p.ServiceName)
+	var out Forecast
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/subscriptions/forecast", query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Renewals возвращает списания в окне
+func (c *Client) Renewals(ctx context.Context, p RenewalsParams) (*Renewals, error) {
+	q := url.Values{}
+	if !p.From.IsZero() {
+		q.Set("from", p.From.Format("2006-01-02"))
+	}
+	if !p.To.IsZero() {
+		q.Set("to", p.To.Format("2006-01-02"))
+	}
+	setUUID(q, "user_id", p.UserID)
+	var out Renewals
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/renewals", query: q}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenewalsCalendarLink возвращает ссылку на ленту продлений пользователя в текущей организации
+func (c *Client) RenewalsCalendarLink(ctx context.Context, userID uuid.UUID) (*CalendarLink, error) {
+	var out CalendarLink
+	path := "/users/" + userID.String() + "/renewals/link"
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenewalsCalendar записывает в w ленту продлений пользователя в формате iCalendar.
+// Лента доступна по токену из RenewalsCalendarLink без аутентификации; организацию определяет токен.
+func (c *Client) RenewalsCalendar(ctx context.Context, userID uuid.UUID, token string, w io.Writer) error {
+	req := request{
+		method: http.MethodGet,
+		path:   "/users/" + userID.String() + "/renewals.ics",
+		query:  url.Values{"token": {token}},
+		accept: "text/calendar",
+	}
+	_, err := c.do(ctx, req, w)
+	return err
+}
+
+func setUUID(q url.Values, key string, id uuid.UUID) {
+	if id != uuid.Nil {
+		q.Set(key, id.String())
+	}
+}
+
+func setString(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
@@ -0,0 +1,304 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MonthLayout формат месяца в датах запросов: MM-YYYY
+const MonthLayout = "01-2006"
+
+// Month форматирует месяц даты t для полей запросов, например StartDate
+func Month(t time.Time) string {
+	return t.Format(MonthLayout)
+}
+
+// Периоды списания подписки
+const (
+	BillingMonthly    = "monthly"
+	BillingQuarterly  = "quarterly"
+	BillingSemiannual = "semiannual"
+	BillingYearly     = "yearly"
+)
+
+// Subscription подписка пользователя
+type Subscription struct {
+	ID            uuid.UUID     `json:"id"`
+	OrgID         uuid.UUID     `json:"org_id"`
+	ServiceName   string        `json:"service_name"`
+	Price         int           `json:"price"`
+	UserID        uuid.UUID     `json:"user_id"`
+	StartDate     time.Time     `json:"start_date"`
+	EndDate       *time.Time    `json:"end_date,omitempty"`
+	BillingPeriod string        `json:"billing_period"`
+	TrialEndDate  *time.Time    `json:"trial_end_date,omitempty"`
+	Pauses        []Pause       `json:"pauses,omitempty"`
+	PriceChanges  []PriceChange `json:"price_changes,omitempty"`
+}
+
+// CreateSubscriptionRequest данные новой подписки. Даты в формате MM-YYYY, см. Month.
+type CreateSubscriptionRequest struct {
+	ServiceName   string    `json:"service_name"`
+	Price         int       `json:"price"`
+	UserID        uuid.UUID `json:"user_id"`
+	StartDate     string    `json:"start_date"`
+	EndDate       *string   `json:"end_date,omitempty"`
+	BillingPeriod string    `json:"billing_period,omitempty"`
+	TrialEndDate  *string   `json:"trial_end_date,omitempty"`
+}
+
+// UpdateSubscriptionRequest частичное изменение подписки: nil-поля не меняются
+type UpdateSubscriptionRequest struct {
+	ServiceName   *string `json:"service_name,omitempty"`
+	Price         *int    `json:"price,omitempty"`
+	StartDate     *string `json:"start_date,omitempty"`
+	EndDate       *string `json:"end_date,omitempty"`
+	BillingPeriod *string `json:"billing_period,omitempty"`
+	TrialEndDate  *string `json:"trial_end_date,omitempty"`
+}
+
+// Pause период приостановки подписки; EndDate == nil — бессрочная пауза
+type Pause struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+}
+
+// CreatePauseRequest данные паузы, даты в формате MM-YYYY
+type CreatePauseRequest struct {
+	StartDate string  `json:"start_date"`
+	EndDate   *string `json:"end_date,omitempty"`
+}
+
+// PriceChange запланированное изменение цены подписки
+type PriceChange struct {
+	ID             uuid.UUID `json:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	EffectiveDate  time.Time `json:"effective_date"`
+	Price          int       `json:"price"`
+}
+
+// CreatePriceChangeRequest данные изменения цены, EffectiveDate в формате MM-YYYY
+type CreatePriceChangeRequest struct {
+	EffectiveDate string `json:"effective_date"`
+	Price         int    `json:"price"`
+}
+
+// SummaryParams фильтры суммы подписок. Период задаётся StartDate и EndDate (MM-YYYY)
+// либо FiscalYear; нулевые значения не передаются.
+type SummaryParams struct {
+	UserID      uuid.UUID
+	ServiceName string
+	StartDate   string
+	EndDate     string
+	FiscalYear  int
+}
+
+// Summary сумма подписок за период
+type Summary struct {
+	TotalPrice int    `json:"total_price"`
+	Currency   string `json:"currency"`
+}
+
+// ForecastParams параметры прогноза; Months == 0 — горизонт сервера по умолчанию
+type ForecastParams struct {
+	Months      int
+	UserID      uuid.UUID
+	ServiceName string
+}
+
+// ForecastMonth прогноз расходов за один месяц
+type ForecastMonth struct {
+	Month     string         `json:"month"`
+	Total     int            `json:"total"`
+	ByUser    map[string]int `json:"by_user"`
+	ByService map[string]int `json:"by_service"`
+}
+
+// Forecast помесячный прогноз расходов
+type Forecast struct {
+	Months    int             `json:"months"`
+	Currency  string          `json:"currency"`
+	Series    []ForecastMonth `json:"series"`
+	Total     int             `json:"total"`
+	ByUser    map[string]int  `json:"by_user"`
+	ByService map[string]int  `json:"by_service"`
+}
+
+// RenewalsParams окно продлений; нулевые From и To — значения сервера по умолчанию
+type RenewalsParams struct {
+	From   time.Time
+	To     time.Time
+	UserID uuid.UUID
+}
+
+// Charge одно списание по подписке
+type Charge struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ServiceName    string    `json:"service_name"`
+	Date           time.Time `json:"date"`
+	Amount         int       `json:"amount"`
+}
+
+// Renewals списания в окне
+type Renewals struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Charges  []Charge `json:"charges"`
+	Total    int      `json:"total"`
+	Currency string   `json:"currency"`
+}
+
+// CalendarLink ссылка на ленту продлений в формате iCalendar
+type CalendarLink struct {
+	OrgID uuid.UUID `json:"org_id"`
+	Token string    `json:"token"`
+	URL   string    `json:"url"`
+}
+
+// Organization организация
+type Organization struct {
+	ID              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	DefaultCurrency string    `json:"default_currency"`
+	FiscalYearStart int       `json:"fiscal_year_start"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// OrganizationRequest данные организации; nil-поля при изменении не меняются
+type OrganizationRequest struct {
+	Name            *string `json:"name,omitempty"`
+	DefaultCurrency *string `json:"default_currency,omitempty"`
+	FiscalYearStart *int    `json:"fiscal_year_start,omitempty"`
+}
+
+// APIKey ключ межсервисного доступа без секрета
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	OrgID      uuid.UUID  `json:"org_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyWithSecret ключ вместе с секретом, который возвращается только при выпуске и ротации
+type APIKeyWithSecret struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest параметры нового ключа; ExpiresAt == nil — бессрочный ключ
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Типы событий вебхуков
+const (
+	EventSubscriptionCreated         = "subscription.created"
+	EventSubscriptionUpdated         = "subscription.updated"
+	EventSubscriptionDeleted         = "subscription.deleted"
+	EventSubscriptionRenewalUpcoming = "subscription.renewal_upcoming"
+	EventSubscriptionEnded           = "subscription.ended"
+)
+
+// Состояния доставки вебхука; DeliveryFailed — список недоставленных
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook зарегистрированный адрес без секрета
+type Webhook struct {
+	ID        uuid.UUID `json:"id"`
+	OrgID     uuid.UUID `json:"org_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookWithSecret адрес вместе с секретом подписи, который возвращается только при регистрации
+type WebhookWithSecret struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookRequest адрес http или https и типы событий Event*
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// WebhookDelivery доставка одного события на один адрес
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	OrgID          uuid.UUID       `json:"org_id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// DeliveryListOptions отбор доставок; пустые поля не ограничивают выборку
+type DeliveryListOptions struct {
+	WebhookID uuid.UUID
+	// Status Delivery*
+	Status string
+	Limit  int
+	After  string
+}
+
+// DeliveryPage страница доставок; NextCursor пуст на последней странице
+type DeliveryPage struct {
+	Items      []WebhookDelivery
+	NextCursor string
+}
+
+// WebhookEvent тело запроса, которое получает адрес вебхука
+type WebhookEvent struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	OrgID     uuid.UUID `json:"org_id"`
+	Data      struct {
+		Subscription Subscription `json:"subscription"`
+		// Charge предстоящее списание, только для subscription.renewal_upcoming
+		Charge *Charge `json:"charge,omitempty"`
+	} `json:"data"`
+}
+
+// ReadinessCheck результат одной проверки готовности
+type ReadinessCheck struct {
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Readiness отчёт /readyz
+type Readiness struct {
+	Status string                    `json:"status"`
+	Checks map[string]ReadinessCheck `json:"checks"`
+}
+
+// Ready сообщает, пройдены ли все проверки
+func (r Readiness) Ready() bool {
+	return r.Status == "ok"
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Заголовки запроса, с которым сервис доставляет событие
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookID        = "X-Webhook-ID"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// DefaultWebhookTolerance допустимое расхождение X-Webhook-Timestamp с часами получателя
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// ErrInvalidSignature подпись или время доставки не прошли проверку
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// CreateWebhook регистрирует адрес для событий организации; секрет подписи есть только в ответе
+func (c *Client) CreateWebhook(ctx context.Context, in CreateWebhookRequest) (*WebhookWithSecret, error) {
+	var out WebhookWithSecret
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: "/webhooks", body: in}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhooks возвращает адреса организации без секретов
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var out []Webhook
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/webhooks"}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteWebhook удаляет адрес id вместе с историей доставок
+func (c *Client) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/webhooks/" + id.String()}, nil)
+	return err
+}
+
+// ListWebhookDeliveries возвращает одну страницу доставок; Status DeliveryFailed — список недоставленных
+func (c *Client) ListWebhookDeliveries(ctx context.Context, opts DeliveryListOptions) (*DeliveryPage, error) {
+	req := request{method: http.MethodGet, path: "/webhooks/deliveries", query: url.Values{}}
+	if opts.WebhookID != uuid.Nil {
+		req.query.Set("webhook_id", opts.WebhookID.String())
+	}
+	if opts.Status != "" {
+		req.query.Set("status", opts.Status)
+	}
+	if opts.Limit > 0 {
+		req.query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.After != "" {
+		req.query.Set("after", opts.After)
+	}
+	page := &DeliveryPage{}
+	header, err := c.do(ctx, req, &page.Items)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = header.Get(nextCursorHeader)
+	return page, nil
+}
+
+// RedeliverWebhook ставит доставку id в очередь заново с обнулённым счётчиком попыток
+func (c *Client) RedeliverWebhook(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	var out WebhookDelivery
+	path := "/webhooks/deliveries/" + id.String() + "/redeliver"
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: path}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifyWebhookSignature проверяет заголовок X-Webhook-Signature для тела body и заголовка
+// X-Webhook-Timestamp. Время должно отличаться от now не больше чем на tolerance, чтобы перехваченный
+// запрос нельзя было повторить позже.
+func VerifyWebhookSignature(secret, signature, timestamp string, body []byte, now time.Time, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrInvalidSignature)
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseWebhook читает запрос доставки, проверяет подпись с допуском DefaultWebhookTolerance
+// и разбирает событие. Одно событие может прийти несколько раз: повторять обработку не нужно,
+// если WebhookEvent.ID уже встречался.
+func ParseWebhook(r *http.Request, secret string) (*WebhookEvent, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read webhook body: %w", err)
+	}
+	err = VerifyWebhookSignature(secret, r.Header.Get(HeaderWebhookSignature), r.Header.Get(HeaderWebhookTimestamp),
+		body, time.Now(), DefaultWebhookTolerance)
+	if err != nil {
+		return nil, err
+	}
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("decode webhook event: %w", err)
+	}
+	return &event, nil
+}